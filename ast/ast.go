@@ -8,7 +8,18 @@ import (
 
 type Node interface {
 	TokenLiteral() string
-	String() string // this will print the AST nodes for debugging purposes and to compare them with other AST notes
+	String() string      // this will print the AST nodes for debugging purposes and to compare them with other AST notes
+	Pos() token.Position // position of the first character of the node
+	End() token.Position // position immediately after the last character of the node
+}
+
+// returns the end of a child node, or the fallback if the child is missing (which happens when the parser had errors).
+func endOf(n Node, fallback token.Position) token.Position {
+	if n == nil {
+		return fallback
+	}
+
+	return n.End()
 }
 
 type Statement interface {
@@ -21,6 +32,12 @@ type Expression interface {
 	expressionNode()
 }
 
+// Pattern is the target of a destructuring let, an *Identifier, *ArrayPattern or *HashPattern.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
@@ -33,6 +50,23 @@ func (il *IntegerLiteral) TokenLiteral() string {
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
+func (il *IntegerLiteral) Pos() token.Position { return il.Token.Pos }
+func (il *IntegerLiteral) End() token.Position { return il.Token.End }
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+func (fl *FloatLiteral) Pos() token.Position { return fl.Token.Pos }
+func (fl *FloatLiteral) End() token.Position { return fl.Token.End }
 
 type PrefixExpression struct {
 	Token    token.Token // The token in consideration
@@ -54,6 +88,8 @@ func (pe *PrefixExpression) String() string {
 
 	return out.String()
 }
+func (pe *PrefixExpression) Pos() token.Position { return pe.Token.Pos }
+func (pe *PrefixExpression) End() token.Position { return endOf(pe.Right, pe.Token.End) }
 
 // Program is our first implementation of the Statement interface
 // Program is a slice of Statement
@@ -77,6 +113,20 @@ func (p *Program) TokenLiteral() string {
 		return ""
 	}
 }
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 && p.Statements[0] != nil {
+		return p.Statements[0].Pos()
+	}
+
+	return token.Position{}
+}
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return endOf(p.Statements[len(p.Statements)-1], token.Position{})
+	}
+
+	return token.Position{}
+}
 
 type Identifier struct {
 	Token token.Token // the token.IDENT token
@@ -87,21 +137,30 @@ func (i *Identifier) String() string {
 	return i.Value
 }
 func (i *Identifier) expressionNode() {}
+func (i *Identifier) patternNode()    {}
 func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
+func (i *Identifier) Pos() token.Position { return i.Token.Pos }
+func (i *Identifier) End() token.Position { return i.Token.End }
 
+// LetStatement is also used for const name = value, then its token is token.CONST.
 type LetStatement struct {
-	Token token.Token // the token.LET token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token // the token.LET or token.CONST token
+	Name    *Identifier
+	Pattern Pattern // set instead of Name for let [a, b] = ... and let {a, b} = ...
+	Value   Expression
 }
 
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -113,9 +172,17 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 func (ls *LetStatement) statementNode() {}
+
+// IsConst reports whether the statement binds constants, which cannot be redefined or assigned to.
+func (ls *LetStatement) IsConst() bool {
+	return ls.Token.Type == token.CONST
+}
+
 func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
+func (ls *LetStatement) Pos() token.Position { return ls.Token.Pos }
+func (ls *LetStatement) End() token.Position { return endOf(ls.Value, ls.Token.End) }
 
 type ReturnStatement struct {
 	Token       token.Token // the token.RETURN token
@@ -139,6 +206,8 @@ func (rs *ReturnStatement) statementNode() {}
 func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
+func (rs *ReturnStatement) Pos() token.Position { return rs.Token.Pos }
+func (rs *ReturnStatement) End() token.Position { return endOf(rs.ReturnValue, rs.Token.End) }
 
 type ExpressionStatement struct { // the "statement" keyword only indicates that this is just a wrapper for a standalone expression 'cause our language supports expression statements
 	Token      token.Token
@@ -156,6 +225,8 @@ func (es *ExpressionStatement) statementNode() {}
 func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
+func (es *ExpressionStatement) Pos() token.Position { return es.Token.Pos }
+func (es *ExpressionStatement) End() token.Position { return endOf(es.Expression, es.Token.End) }
 
 type InfixExpression struct { // fullfils the Expression and Node interfaces.
 	Token    token.Token // the operator token, e.g. +
@@ -178,6 +249,157 @@ func (oe *InfixExpression) String() string {
 
 	return out.String()
 }
+func (oe *InfixExpression) Pos() token.Position {
+	if oe.Left != nil {
+		return oe.Left.Pos()
+	}
+
+	return oe.Token.Pos
+}
+func (oe *InfixExpression) End() token.Position { return endOf(oe.Right, oe.Token.End) }
+
+// ArrayPattern destructures an array, e.g. [a, [b, c], ...tail].
+type ArrayPattern struct {
+	Token    token.Token // the '[' token
+	Elements []Pattern
+	Rest     *Identifier // binds the remaining elements, nil if there is no ...rest
+	Rbracket token.Token // the ']' token
+}
+
+func (ap *ArrayPattern) patternNode()         {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) Pos() token.Position  { return ap.Token.Pos }
+func (ap *ArrayPattern) End() token.Position  { return ap.Rbracket.End }
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashPatternPair binds the value at Key to the pattern, {name} is short for {name: name}.
+type HashPatternPair struct {
+	Key   *StringLiteral
+	Value Pattern
+}
+
+// HashPattern destructures a hash, e.g. {name, age: years, address: {city}}.
+type HashPattern struct {
+	Token  token.Token // the '{' token
+	Pairs  []*HashPatternPair
+	Rbrace token.Token // the '}' token
+}
+
+func (hp *HashPattern) patternNode()         {}
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) Pos() token.Position  { return hp.Token.Pos }
+func (hp *HashPattern) End() token.Position  { return hp.Rbrace.End }
+func (hp *HashPattern) String() string {
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		if ident, ok := pair.Value.(*Identifier); ok && ident.Value == pair.Key.Value {
+			pairs = append(pairs, ident.String())
+		} else {
+			pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+		}
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// LiteralPattern matches values equal to a literal, e.g. 1, -2.5, "text" or true.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode()         {}
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Value.TokenLiteral() }
+func (lp *LiteralPattern) String() string       { return lp.Value.String() }
+func (lp *LiteralPattern) Pos() token.Position  { return lp.Value.Pos() }
+func (lp *LiteralPattern) End() token.Position  { return lp.Value.End() }
+
+// Match expressions
+
+// MatchArm is pattern if guard => body, the guard is nil if there is none.
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression
+	Body    Expression
+}
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if " + ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
+
+type MatchExpression struct {
+	Token   token.Token // the match token
+	Subject Expression
+	Arms    []*MatchArm
+	Rbrace  token.Token // the '}' token
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) Pos() token.Position  { return me.Token.Pos }
+func (me *MatchExpression) End() token.Position  { return me.Rbrace.End }
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	out.WriteString("match(")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// AssignExpression is x = value, x += value and friends, or xs[i] = value.
+type AssignExpression struct {
+	Token    token.Token // the assignment operator token, e.g. = or +=
+	Target   Expression  // *Identifier, *IndexExpression or *MemberExpression
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode()      {}
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+
+	return out.String()
+}
+func (ae *AssignExpression) Pos() token.Position {
+	if ae.Target != nil {
+		return ae.Target.Pos()
+	}
+
+	return ae.Token.Pos
+}
+func (ae *AssignExpression) End() token.Position { return endOf(ae.Value, ae.Token.End) }
 
 // Booleans
 
@@ -194,6 +416,8 @@ func (b *Boolean) TokenLiteral() string {
 func (b *Boolean) String() string {
 	return b.Token.Literal
 }
+func (b *Boolean) Pos() token.Position { return b.Token.Pos }
+func (b *Boolean) End() token.Position { return b.Token.End }
 
 // If expressions
 
@@ -218,15 +442,114 @@ func (ie *IfExpression) String() string {
 
 	if ie.Alternative != nil {
 		out.WriteString("else ")
-		out.WriteString(ie.Alternative.String())
+		if ie.IsElseIf() {
+			// print the chain flat instead of as nested blocks
+			out.WriteString(ie.Alternative.Statements[0].(*ExpressionStatement).Expression.String())
+		} else {
+			out.WriteString(ie.Alternative.String())
+		}
+	}
+
+	return out.String()
+}
+func (ie *IfExpression) Pos() token.Position { return ie.Token.Pos }
+
+// IsElseIf reports whether the alternative was written as else if (...) { ... },
+// the parser desugars it into a block whose token is the if of the nested expression.
+func (ie *IfExpression) IsElseIf() bool {
+	return ie.Alternative != nil && ie.Alternative.Token.Type == token.IF &&
+		len(ie.Alternative.Statements) == 1
+}
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+
+	return endOf(ie.Consequence, ie.Token.End)
+}
+
+// While loops
+
+type WhileStatement struct {
+	Token     token.Token // the while token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {}
+func (ws *WhileStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+
+	return out.String()
+}
+func (ws *WhileStatement) Pos() token.Position { return ws.Token.Pos }
+func (ws *WhileStatement) End() token.Position { return endOf(ws.Body, ws.Token.End) }
+
+// For loops
+
+type ForStatement struct {
+	Token     token.Token   // the for token
+	Variables []*Identifier // one or two loop variables, e.g. for (x in xs) or for (k, v in hash)
+	Iterable  Expression
+	Body      *BlockStatement
+}
+
+func (fs *ForStatement) statementNode() {}
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	variables := []string{}
+	for _, v := range fs.Variables {
+		variables = append(variables, v.String())
 	}
 
+	out.WriteString("for(")
+	out.WriteString(strings.Join(variables, ", "))
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
 	return out.String()
 }
+func (fs *ForStatement) Pos() token.Position { return fs.Token.Pos }
+func (fs *ForStatement) End() token.Position { return endOf(fs.Body, fs.Token.End) }
+
+type BreakStatement struct {
+	Token token.Token // the break token
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) String() string       { return "break;" }
+func (bs *BreakStatement) Pos() token.Position  { return bs.Token.Pos }
+func (bs *BreakStatement) End() token.Position  { return bs.Token.End }
+
+type ContinueStatement struct {
+	Token token.Token // the continue token
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) String() string       { return "continue;" }
+func (cs *ContinueStatement) Pos() token.Position  { return cs.Token.Pos }
+func (cs *ContinueStatement) End() token.Position  { return cs.Token.End }
 
 type BlockStatement struct {
 	Token      token.Token // the { token
 	Statements []Statement
+	Rbrace     token.Token // the } token
 }
 
 func (bs *BlockStatement) statementNode() {}
@@ -242,13 +565,23 @@ func (bs *BlockStatement) String() string {
 
 	return out.String()
 }
+func (bs *BlockStatement) Pos() token.Position { return bs.Token.Pos }
+func (bs *BlockStatement) End() token.Position { return bs.Rbrace.End }
 
 // nodes of functions
 
 type FunctionLiteral struct {
-	Token      token.Token // fn token
+	Token      token.Token // fn token, or the first token of an arrow function
 	Parameters []*Identifier
+	Defaults   []Expression // the default value of each parameter, nil if it has none
+	Rest       *Identifier  // the ...rest parameter which collects extra arguments, nil if there is none
 	Body       *BlockStatement
+	Arrow      token.Token // the => of an arrow function like x => x * 2, empty for fn literals
+}
+
+// IsArrow reports whether the function was written as an arrow function, its body is then a single expression.
+func (fl *FunctionLiteral) IsArrow() bool {
+	return fl.Arrow.Type == token.ARROW
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -259,8 +592,24 @@ func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
+	for i, p := range fl.Parameters {
+		if i < len(fl.Defaults) && fl.Defaults[i] != nil {
+			params = append(params, p.String()+" = "+fl.Defaults[i].String())
+		} else {
+			params = append(params, p.String())
+		}
+	}
+	if fl.Rest != nil {
+		params = append(params, "..."+fl.Rest.String())
+	}
+
+	if fl.IsArrow() {
+		out.WriteString("(")
+		out.WriteString(strings.Join(params, ", "))
+		out.WriteString(") => ")
+		out.WriteString(fl.Body.String())
+
+		return out.String()
 	}
 
 	out.WriteString(fl.TokenLiteral())
@@ -271,6 +620,8 @@ func (fl *FunctionLiteral) String() string {
 
 	return out.String()
 }
+func (fl *FunctionLiteral) Pos() token.Position { return fl.Token.Pos }
+func (fl *FunctionLiteral) End() token.Position { return endOf(fl.Body, fl.Token.End) }
 
 // Call Expressions
 
@@ -278,6 +629,7 @@ type CallExpression struct {
 	Token     token.Token // the '(' Token
 	Function  Expression  // Identifier or FunctionLiteral
 	Arguments []Expression
+	Rparen    token.Token // the ')' Token
 }
 
 func (ce *CallExpression) expressionNode() {}
@@ -299,6 +651,14 @@ func (ce *CallExpression) String() string {
 
 	return out.String()
 }
+func (ce *CallExpression) Pos() token.Position {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+
+	return ce.Token.Pos
+}
+func (ce *CallExpression) End() token.Position { return ce.Rparen.End }
 
 type StringLiteral struct {
 	Token token.Token
@@ -308,14 +668,47 @@ type StringLiteral struct {
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position  { return sl.Token.Pos }
+func (sl *StringLiteral) End() token.Position  { return sl.Token.End }
+
+// InterpolatedString is a string with embedded ${expressions}, the parts are
+// *StringLiteral for the text and any expression for the embedded parts.
+type InterpolatedString struct {
+	Token    token.Token // the INTERP_START token
+	Parts    []Expression
+	EndToken token.Token // the INTERP_END token
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("\"")
+	for _, part := range is.Parts {
+		if str, ok := part.(*StringLiteral); ok {
+			out.WriteString(str.Value)
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString("\"")
+
+	return out.String()
+}
+func (is *InterpolatedString) Pos() token.Position { return is.Token.Pos }
+func (is *InterpolatedString) End() token.Position { return is.EndToken.End }
 
 type ArrayLiteral struct {
-	Token    token.Token
+	Token    token.Token // the '[' token
 	Elements []Expression
+	Rbracket token.Token // the ']' token
 }
 
 func (al *ArrayLiteral) expressionNode()      {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() token.Position  { return al.Token.Pos }
+func (al *ArrayLiteral) End() token.Position  { return al.Rbracket.End }
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 	elements := []string{}
@@ -332,13 +725,22 @@ func (al *ArrayLiteral) String() string {
 }
 
 type IndexExpression struct {
-	Token token.Token
-	Left  Expression
-	Index Expression
+	Token    token.Token // the '[' token
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token // the ']' token
 }
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+
+	return ie.Token.Pos
+}
+func (ie *IndexExpression) End() token.Position { return ie.Rbracket.End }
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
@@ -351,13 +753,75 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// MemberExpression is value.name, which reads the key "name" of a hash.
+// As the function of a call, value.name(args) calls a method on the value.
+type MemberExpression struct {
+	Token    token.Token // the '.' token
+	Object   Expression
+	Property *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) Pos() token.Position {
+	if me.Object != nil {
+		return me.Object.Pos()
+	}
+
+	return me.Token.Pos
+}
+func (me *MemberExpression) End() token.Position { return endOf(me.Property, me.Token.End) }
+func (me *MemberExpression) String() string {
+	return "(" + me.Object.String() + "." + me.Property.String() + ")"
+}
+
+// SliceExpression is xs[low:high], Low and High are nil when they are left out.
+type SliceExpression struct {
+	Token    token.Token // the '[' token
+	Left     Expression
+	Low      Expression
+	High     Expression
+	Rbracket token.Token // the ']' token
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) Pos() token.Position {
+	if se.Left != nil {
+		return se.Left.Pos()
+	}
+
+	return se.Token.Pos
+}
+func (se *SliceExpression) End() token.Position { return se.Rbracket.End }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
 type HashLiteral struct {
-	Token token.Token
-	Pairs map[Expression]Expression
+	Token  token.Token // the '{' token
+	Pairs  map[Expression]Expression
+	Rbrace token.Token // the '}' token
 }
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position  { return hl.Token.Pos }
+func (hl *HashLiteral) End() token.Position  { return hl.Rbrace.End }
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
@@ -3,6 +3,7 @@ package evaluator
 import (
 	"fmt"
 	"monkeylang/object"
+	"unicode/utf8"
 )
 
 var builtins = map[string]*object.Builtin{
@@ -16,13 +17,29 @@ var builtins = map[string]*object.Builtin{
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				// strings are measured in characters, `bytelen` gives the size in bytes.
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			default:
 				return newError("argument to `len` not supported, got %s", args[0].Type())
 			}
 		},
 	},
 
+	"bytelen": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong no. of arguments. got = %d, want = 1", len(args))
+			}
+
+			arg, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `bytelen` must be String, got = %s", args[0].Type())
+			}
+
+			return &object.Integer{Value: int64(len(arg.Value))}
+		},
+	},
+
 	"first": {
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
@@ -112,6 +129,38 @@ var builtins = map[string]*object.Builtin{
 			return &object.Array{Elements: newElements}
 		},
 	},
+	"range": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong no. of arguments. got = %d, want = 1 to 3", len(args))
+			}
+
+			bounds := []int64{}
+			for _, arg := range args {
+				integer, ok := arg.(*object.Integer)
+				if !ok {
+					return newError("arguments to `range` must be Integer, got = %s", arg.Type())
+				}
+				bounds = append(bounds, integer.Value)
+			}
+
+			r := &object.Range{Start: 0, Step: 1}
+			switch len(bounds) {
+			case 1:
+				r.End = bounds[0]
+			case 2:
+				r.Start, r.End = bounds[0], bounds[1]
+			case 3:
+				r.Start, r.End, r.Step = bounds[0], bounds[1], bounds[2]
+			}
+
+			if r.Step == 0 {
+				return newError("step of `range` must not be zero")
+			}
+
+			return r
+		},
+	},
     "puts": {
         Fn: func(args ...object.Object) object.Object {
             for _, arg := range args {
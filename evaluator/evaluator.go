@@ -2,8 +2,12 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
 	"monkeylang/ast"
 	"monkeylang/object"
+	"sort"
+	"strings"
+	"unicode/utf8"
 )
 
 var (
@@ -11,9 +15,28 @@ var (
 	FALSE = &object.Boolean{Value: false}
 
 	NULL = &object.Boolean{}
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
+// Strict makes the evaluator report an error where it would otherwise quietly use null,
+// e.g. for indexes out of range or elements which are missing when destructuring.
+var Strict = false
+
+// evaluates the node and, if it produced an error, records where the error happened.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	result := eval(node, env)
+
+	// the innermost node which failed is the most precise position, so we only set it once.
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() && node != nil {
+		err.Pos = node.Pos()
+	}
+
+	return result
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	// statements
 	case *ast.Program:
@@ -28,12 +51,29 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
+
 	case *ast.LetStatement:
 		val := Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if node.Pattern != nil {
+			if err := bindPattern(node.Pattern, val, env, node.IsConst()); err != nil {
+				return err
+			}
+			return nil
+		}
+		if err := define(env, node.Name.Value, val, node.IsConst()); err != nil {
+			return err
+		}
 		// TODO : Start from here
 
 	case *ast.ReturnStatement:
@@ -45,6 +85,11 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 
 	// expression
 	case *ast.InfixExpression:
+		// the right side of && and || is only evaluated if it is needed.
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
+
 		left := Eval(node.Left, env) // left of the operator
 		if isError(left) {
 			return left
@@ -64,8 +109,12 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{Parameters: params, Defaults: node.Defaults, Rest: node.Rest, Body: body, Env: env}
 	case *ast.CallExpression:
+		if member, ok := node.Function.(*ast.MemberExpression); ok {
+			return evalMethodCall(member, node.Arguments, env)
+		}
+
 		function := Eval(node.Function, env)
 		if isError(function) {
 			return function
@@ -79,12 +128,16 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value} // returns an integer object of our internal representation of our language.
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.Identifier:
 		return evalIdent(node, env)
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -104,8 +157,20 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 
 		return evalIndexExpression(left, index)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+	case *ast.MemberExpression:
+		receiver := Eval(node.Object, env)
+		if isError(receiver) {
+			return receiver
+		}
+		return evalMemberExpression(receiver, node.Property.Value)
     case *ast.HashLiteral:
         return evalHashLiteral(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
 	}
 
 	return nil
@@ -140,10 +205,335 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
     return &object.Hash{Pairs: pairs}
 }
 
+// every part is turned into text the same way the REPL prints it.
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		value := Eval(part, env)
+		if isError(value) {
+			return value
+		}
+		if value == nil {
+			value = NULL
+		}
+		out.WriteString(value.Inspect())
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+// binds name with let or const, it returns an error or nil.
+func define(env *object.Environment, name string, val object.Object, constant bool) object.Object {
+	var result object.Object
+	if constant {
+		result = env.SetConst(name, val)
+	} else {
+		result = env.Set(name, val)
+	}
+
+	if isError(result) {
+		return result
+	}
+	return nil
+}
+
+// binds the names in the pattern to the matching parts of the value, it returns an error or nil.
+func bindPattern(pattern ast.Pattern, val object.Object, env *object.Environment, constant bool) object.Object {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		return define(env, pattern.Value, val, constant)
+
+	case *ast.ArrayPattern:
+		array, ok := val.(*object.Array)
+		if !ok {
+			return newError("cannot destructure %s as ARRAY", val.Type())
+		}
+
+		for i, element := range pattern.Elements {
+			var item object.Object = NULL
+			if i < len(array.Elements) {
+				item = array.Elements[i]
+			} else if Strict {
+				return newError("index out of range: %d", i)
+			}
+
+			if err := bindPattern(element, item, env, constant); err != nil {
+				return err
+			}
+		}
+
+		if pattern.Rest != nil {
+			rest := []object.Object{}
+			if len(array.Elements) > len(pattern.Elements) {
+				rest = append(rest, array.Elements[len(pattern.Elements):]...)
+			}
+			return define(env, pattern.Rest.Value, &object.Array{Elements: rest}, constant)
+		}
+
+	case *ast.HashPattern:
+		hash, ok := val.(*object.Hash)
+		if !ok {
+			return newError("cannot destructure %s as HASH", val.Type())
+		}
+
+		for _, pair := range pattern.Pairs {
+			key := &object.String{Value: pair.Key.Value}
+
+			var item object.Object = NULL
+			if found, ok := hash.Pairs[key.HashKey()]; ok {
+				item = found.Value
+			} else if Strict {
+				return newError("key not found: %s", pair.Key.Value)
+			}
+
+			if err := bindPattern(pair.Value, item, env, constant); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func evalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(me.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range me.Arms {
+		// every arm binds its names in its own environment, so a failed arm leaves nothing behind
+		armEnv := object.NewEnclosedEnvironment(env)
+
+		matched, err := matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return Eval(arm.Body, armEnv)
+	}
+
+	return newError("non-exhaustive match: no pattern matches %s", subject.Inspect())
+}
+
+// reports whether the value has the shape of the pattern and binds its names if it does.
+func matchPattern(pattern ast.Pattern, val object.Object, env *object.Environment) (bool, object.Object) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		// _ matches everything without binding it
+		if pattern.Value != "_" {
+			env.Set(pattern.Value, val)
+		}
+		return true, nil
+
+	case *ast.LiteralPattern:
+		literal := Eval(pattern.Value, env)
+		if isError(literal) {
+			return false, literal
+		}
+		return literalMatches(literal, val), nil
+
+	case *ast.ArrayPattern:
+		array, ok := val.(*object.Array)
+		if !ok {
+			return false, nil
+		}
+		if len(array.Elements) < len(pattern.Elements) ||
+			(pattern.Rest == nil && len(array.Elements) != len(pattern.Elements)) {
+			return false, nil
+		}
+
+		for i, element := range pattern.Elements {
+			matched, err := matchPattern(element, array.Elements[i], env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+
+		if pattern.Rest != nil {
+			rest := append([]object.Object{}, array.Elements[len(pattern.Elements):]...)
+			env.Set(pattern.Rest.Value, &object.Array{Elements: rest})
+		}
+		return true, nil
+
+	case *ast.HashPattern:
+		hash, ok := val.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+
+		for _, pair := range pattern.Pairs {
+			key := &object.String{Value: pair.Key.Value}
+			found, ok := hash.Pairs[key.HashKey()]
+			if !ok {
+				return false, nil
+			}
+
+			matched, err := matchPattern(pair.Value, found.Value, env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// compares a literal of a pattern with a value, numbers are equal across integers and floats.
+func literalMatches(literal, val object.Object) bool {
+	if literal.Type() == object.INTEGER_OBJ && val.Type() == object.INTEGER_OBJ {
+		return literal.(*object.Integer).Value == val.(*object.Integer).Value
+	}
+	if isNumber(literal) && isNumber(val) {
+		return toFloat(literal) == toFloat(val)
+	}
+
+	switch literal := literal.(type) {
+	case *object.String:
+		str, ok := val.(*object.String)
+		return ok && str.Value == literal.Value
+	case *object.Boolean:
+		b, ok := val.(*object.Boolean)
+		return ok && val != NULL && b.Value == literal.Value
+	}
+
+	return false
+}
+
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		current, ok := env.Get(target.Value)
+		if !ok {
+			return newError("cannot assign to undeclared identifier: %s", target.Value)
+		}
+
+		val = applyAssignOperator(node.Operator, current, val)
+		if isError(val) {
+			return val
+		}
+
+		// the result is an error if the name is a constant
+		result, _ := env.Assign(target.Value, val)
+		return result
+
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+
+		if node.Operator != "=" {
+			current := evalIndexExpression(left, index)
+			if isError(current) {
+				return current
+			}
+
+			val = applyAssignOperator(node.Operator, current, val)
+			if isError(val) {
+				return val
+			}
+		}
+
+		return evalIndexAssignment(left, index, val)
+
+	case *ast.MemberExpression:
+		// person.name = value is the same as person["name"] = value
+		receiver := Eval(target.Object, env)
+		if isError(receiver) {
+			return receiver
+		}
+		if receiver.Type() != object.HASH_OBJ {
+			return newError("member access not supported: %s.%s", receiver.Type(), target.Property.Value)
+		}
+
+		if node.Operator != "=" {
+			current := evalMemberExpression(receiver, target.Property.Value)
+			if isError(current) {
+				return current
+			}
+
+			val = applyAssignOperator(node.Operator, current, val)
+			if isError(val) {
+				return val
+			}
+		}
+
+		return evalIndexAssignment(receiver, &object.String{Value: target.Property.Value}, val)
+
+	default:
+		return newError("cannot assign to %s", node.Target.String())
+	}
+}
+
+// computes the new value of a compound assignment like x += 1, plain = keeps the value.
+func applyAssignOperator(operator string, current, val object.Object) object.Object {
+	if operator == "=" {
+		return val
+	}
+
+	return evalInfixExpression(strings.TrimSuffix(operator, "="), current, val)
+}
+
+func evalIndexAssignment(left, index, val object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		arrayObject := left.(*object.Array)
+		idx, ok := normalizeIndex(index.(*object.Integer).Value, len(arrayObject.Elements))
+
+		if !ok {
+			return newError("index out of range: %d", index.(*object.Integer).Value)
+		}
+
+		arrayObject.Elements[idx] = val
+		return val
+
+	case left.Type() == object.HASH_OBJ:
+		hashObject := left.(*object.Hash)
+
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+
+		hashObject.Pairs[key.HashKey()] = object.HashPair{Key: index, Value: val}
+		return val
+
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+}
+
 func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
     case left.Type() == object.HASH_OBJ:
         return evalHashIndexExpression(left, index)
 	default:
@@ -153,17 +543,159 @@ func evalIndexExpression(left, index object.Object) object.Object {
 
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-
-	max := int64(len(arrayObject.Elements) - 1)
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(arrayObject.Elements))
 
-	if idx < 0 || idx > max {
-		return NULL
+	if !ok {
+		return outOfRange(index.(*object.Integer).Value)
 	}
 
 	return arrayObject.Elements[idx]
 }
 
+// turns a negative index into one counted from the end, like xs[-1] for the last element,
+// and reports whether the index is inside a sequence of the given length.
+func normalizeIndex(idx int64, length int) (int64, bool) {
+	if idx < 0 {
+		idx += int64(length)
+	}
+
+	return idx, idx >= 0 && idx < int64(length)
+}
+
+// the result of indexing out of range, null or an error in strict mode.
+func outOfRange(idx int64) object.Object {
+	if Strict {
+		return newError("index out of range: %d", idx)
+	}
+
+	return NULL
+}
+
+// reads value.name, which is the same as value["name"] for hashes.
+func evalMemberExpression(obj object.Object, name string) object.Object {
+	hash, ok := obj.(*object.Hash)
+	if !ok {
+		return newError("member access not supported: %s.%s", obj.Type(), name)
+	}
+
+	// value.name is value["name"]
+	return evalHashIndexExpression(hash, &object.String{Value: name})
+}
+
+// calls value.name(args), a function stored under name in a hash receiver is called with the
+// arguments, otherwise the builtin name is called with the receiver as its first argument.
+func evalMethodCall(member *ast.MemberExpression, arguments []ast.Expression, env *object.Environment) object.Object {
+	receiver := Eval(member.Object, env)
+	if isError(receiver) {
+		return receiver
+	}
+	args := evalExpressions(arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+
+	name := member.Property.Value
+
+	if hash, ok := receiver.(*object.Hash); ok {
+		key := &object.String{Value: name}
+		if pair, ok := hash.Pairs[key.HashKey()]; ok {
+			return applyFunction(pair.Value, args)
+		}
+	}
+
+	if builtin, ok := builtins[name]; ok {
+		return applyFunction(builtin, append([]object.Object{receiver}, args...))
+	}
+
+	return newError("unknown method: %s.%s", receiver.Type(), name)
+}
+
+func evalSliceExpression(se *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(se.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	var length int
+	switch left := left.(type) {
+	case *object.Array:
+		length = len(left.Elements)
+	case *object.String:
+		length = utf8.RuneCountInString(left.Value)
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+
+	low, err := evalSliceBound(se.Low, env, 0, length)
+	if err != nil {
+		return err
+	}
+	high, err := evalSliceBound(se.High, env, length, length)
+	if err != nil {
+		return err
+	}
+	if low > high {
+		low = high
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		elements := make([]object.Object, high-low)
+		copy(elements, left.Elements[low:high])
+		return &object.Array{Elements: elements}
+	default:
+		chars := []rune(left.(*object.String).Value)
+		return &object.String{Value: string(chars[low:high])}
+	}
+}
+
+// evaluates a bound of a slice, negative bounds count from the end and bounds
+// out of range are clamped to the sequence, except in strict mode.
+func evalSliceBound(node ast.Expression, env *object.Environment, fallback, length int) (int, object.Object) {
+	if node == nil {
+		return fallback, nil
+	}
+
+	bound := Eval(node, env)
+	if isError(bound) {
+		return 0, bound
+	}
+	integer, ok := bound.(*object.Integer)
+	if !ok {
+		return 0, newError("slice index must be INTEGER, got %s", bound.Type())
+	}
+
+	idx := integer.Value
+	if idx < 0 {
+		idx += int64(length)
+	}
+
+	if idx < 0 || idx > int64(length) {
+		if Strict {
+			return 0, newError("slice index out of range: %d", integer.Value)
+		}
+		if idx < 0 {
+			idx = 0
+		} else {
+			idx = int64(length)
+		}
+	}
+
+	return int(idx), nil
+}
+
+// strings are indexed by character, the result is a string with that single character.
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	chars := []rune(str.(*object.String).Value)
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(chars))
+
+	if !ok {
+		return outOfRange(index.(*object.Integer).Value)
+	}
+
+	return &object.String{Value: string(chars[idx])}
+}
+
 func evalHashIndexExpression(hash, index object.Object) object.Object {
     hashObject := hash.(*object.Hash)
 
@@ -175,6 +707,9 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 
     pair, ok := hashObject.Pairs[key.HashKey()]
     if !ok {
+        if Strict {
+            return newError("key not found: %s", index.Inspect())
+        }
         return NULL
     }
 
@@ -184,8 +719,14 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 func applyFunction(fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
+		extendedEnv, err := extendFunctionEnv(fn, args)
+		if err != nil {
+			return err
+		}
 		evaluated := Eval(fn.Body, extendedEnv)
+		if isLoopSignal(evaluated) {
+			return newError("%s outside of loop", evaluated.Inspect())
+		}
 		return unwrapReturnValue(evaluated)
 
 	case *object.Builtin:
@@ -197,14 +738,39 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+func extendFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, object.Object) {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
+	if fn.Rest == nil && len(args) > len(fn.Parameters) {
+		return nil, newError("wrong number of arguments: want at most %d, got %d", len(fn.Parameters), len(args))
+	}
+
 	for paramIndx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIndx])
+		if paramIndx < len(args) {
+			env.Set(param.Value, args[paramIndx])
+			continue
+		}
+
+		// defaults are evaluated in the function environment, so they can use the parameters before them
+		if paramIndx >= len(fn.Defaults) || fn.Defaults[paramIndx] == nil {
+			return nil, newError("wrong number of arguments: missing argument for %s", param.Value)
+		}
+		value := Eval(fn.Defaults[paramIndx], env)
+		if isError(value) {
+			return nil, value
+		}
+		env.Set(param.Value, value)
+	}
+
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		env.Set(fn.Rest.Value, &object.Array{Elements: rest})
 	}
 
-	return env
+	return env, nil
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -258,6 +824,186 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	}
 }
 
+// && and || short-circuit, they always result in a boolean.
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	if node.Operator == "&&" && !isTruthy(left) {
+		return FALSE
+	}
+	if node.Operator == "||" && isTruthy(left) {
+		return TRUE
+	}
+
+	right := Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
+// runs the body as long as the condition is truthy. a return or an error inside the body
+// stops the loop and is passed on, like in evalBlockStatement.
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(ws.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return nil
+		}
+
+		// let and const in the body belong to one iteration, assignments still reach the outer bindings.
+		result := Eval(ws.Body, object.NewEnclosedEnvironment(env))
+
+		if result != nil {
+			rt := result.Type()
+
+			if rt == object.BREAK_OBJ {
+				return nil
+			}
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return result
+			}
+		}
+	}
+}
+
+func evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(fs.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	// each step binds the loop variables and runs the body, it reports false when the loop has to stop.
+	var result object.Object
+	step := func(key, value object.Object) bool {
+		var err object.Object
+		if len(fs.Variables) == 1 {
+			err = define(env, fs.Variables[0].Value, value, false)
+		} else if err = define(env, fs.Variables[0].Value, key, false); err == nil {
+			err = define(env, fs.Variables[1].Value, value, false)
+		}
+		if err != nil {
+			result = err
+			return false
+		}
+
+		evaluated := Eval(fs.Body, object.NewEnclosedEnvironment(env))
+
+		if evaluated != nil {
+			rt := evaluated.Type()
+
+			if rt == object.BREAK_OBJ {
+				return false
+			}
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				result = evaluated
+				return false
+			}
+		}
+		return true
+	}
+
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		for i, el := range iterable.Elements {
+			if !step(&object.Integer{Value: int64(i)}, el) {
+				break
+			}
+		}
+
+	case *object.String:
+		i := 0
+		for _, r := range iterable.Value {
+			if !step(&object.Integer{Value: int64(i)}, &object.String{Value: string(r)}) {
+				break
+			}
+			i++
+		}
+
+	case *object.Range:
+		n := iterable.Start
+		for i := int64(0); (iterable.Step > 0 && n < iterable.End) || (iterable.Step < 0 && n > iterable.End); i++ {
+			if !step(&object.Integer{Value: i}, &object.Integer{Value: n}) {
+				break
+			}
+			// near the limits of int64 adding the step would wrap around instead of passing the end.
+			if !rangeHasNext(n, iterable.End, iterable.Step) {
+				break
+			}
+			n += iterable.Step
+		}
+
+	case *object.Hash:
+		// a single loop variable takes the keys of the hash
+		for _, pair := range sortedPairs(iterable) {
+			value := pair.Value
+			if len(fs.Variables) == 1 {
+				value = pair.Key
+			}
+			if !step(pair.Key, value) {
+				break
+			}
+		}
+
+	default:
+		return newError("cannot iterate over %s", iterable.Type())
+	}
+
+	return result
+}
+
+// reports whether n + step is still before end, the distance is unsigned so it cannot overflow.
+func rangeHasNext(n, end, step int64) bool {
+	if step > 0 {
+		return uint64(end)-uint64(n) > uint64(step)
+	}
+	return uint64(n)-uint64(end) > -uint64(step)
+}
+
+// returns the pairs of the hash ordered by key, so that loops over a hash always run in the same order.
+func sortedPairs(hash *object.Hash) []object.HashPair {
+	pairs := make([]object.HashPair, 0, len(hash.Pairs))
+	for _, pair := range hash.Pairs {
+		pairs = append(pairs, pair)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i].Key, pairs[j].Key
+		if a.Type() != b.Type() {
+			return a.Type() < b.Type()
+		}
+
+		switch a := a.(type) {
+		case *object.Integer:
+			return a.Value < b.(*object.Integer).Value
+		case *object.Float:
+			return a.Value < b.(*object.Float).Value
+		case *object.String:
+			return a.Value < b.(*object.String).Value
+		case *object.Boolean:
+			return !a.Value && b.(*object.Boolean).Value
+		}
+		return false
+	})
+
+	return pairs
+}
+
+func isLoopSignal(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.BREAK_OBJ || obj.Type() == object.CONTINUE_OBJ
+	}
+	return false
+}
+
 func evalInfixExpression(
 	operator string,
 	left, right object.Object,
@@ -265,6 +1011,9 @@ func evalInfixExpression(
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		// at least one of them is a float, so the integer is promoted to a float.
+		return evalFloatInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case operator == "!=":
@@ -310,14 +1059,54 @@ func evalIntegerInfixExpression(
 		return &object.Integer{Value: left.(*object.Integer).Value * right.(*object.Integer).Value}
 
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
 		return &object.Integer{Value: left.(*object.Integer).Value / right.(*object.Integer).Value}
 
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero")
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+
+	case "**":
+		// a negative exponent gives a fraction, so the result is a float then
+		if rightVal < 0 {
+			return &object.Float{Value: math.Pow(float64(leftVal), float64(rightVal))}
+		}
+		return &object.Integer{Value: integerPower(leftVal, rightVal)}
+
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+
+	case "<<", ">>":
+		if rightVal < 0 || rightVal > 63 {
+			return newError("invalid shift count: %d, must be between 0 and 63", rightVal)
+		}
+		if operator == "<<" {
+			return &object.Integer{Value: leftVal << rightVal}
+		}
+		return &object.Integer{Value: leftVal >> rightVal}
+
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
 
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 
@@ -329,12 +1118,101 @@ func evalIntegerInfixExpression(
 	}
 }
 
+func evalFloatInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero")
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
+
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// integers and floats can be mixed in arithmetic.
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+// raises base to a non-negative exponent by squaring, it wraps around on overflow like the other integer operators.
+func integerPower(base, exponent int64) int64 {
+	result := int64(1)
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			result *= base
+		}
+		base *= base
+		exponent >>= 1
+	}
+
+	return result
+}
+
+// converts an integer or float object to a float64, the object has to be a number.
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
 func evalPrefixExpression(operator string, right object.Object) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalPrefixMinusOperator(right)
+	case "~":
+		integer, ok := right.(*object.Integer)
+		if !ok {
+			return newError("unknown operator: ~%s", right.Type())
+		}
+		return &object.Integer{Value: ^integer.Value}
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
 	}
@@ -354,13 +1232,14 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalPrefixMinusOperator(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: -right.Value}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-
-	value := right.(*object.Integer).Value
-
-	return &object.Integer{Value: -value}
 }
 
 func nativeBoolToBooleanObject(input bool) *object.Boolean {
@@ -382,6 +1261,8 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 			return result.Value
 		case *object.Error:
 			return result
+		case *object.Break, *object.Continue:
+			return newError("%s outside of loop", result.Inspect())
 		}
 	}
 	return result
@@ -395,7 +1276,7 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 		if result != nil {
 			rt := result.Type()
 
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ || rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
 				return result
 			}
 		}
@@ -134,6 +134,14 @@ func TestArrayIndexExpressions(t *testing.T) {
         },
         {
             "[1, 2, 3][-1]",
+            3,
+        },
+        {
+            "[1, 2, 3][-3]",
+            1,
+        },
+        {
+            "[1, 2, 3][-4]",
             nil,
         },
     }
@@ -149,6 +157,173 @@ func TestArrayIndexExpressions(t *testing.T) {
     }
 }
 
+func TestStringIndexExpressions(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected interface{}
+    }{
+        {`"hello"[0]`, "h"},
+        {`"héllo"[1]`, "é"},
+        {`"日本語"[2]`, "語"},
+        {`"abc"[3]`, nil},
+        {`"héllo"[-1]`, "o"},
+        {`"héllo"[-4]`, "é"},
+        {`"abc"[-4]`, nil},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        expected, ok := tt.expected.(string)
+        if !ok {
+            testNullObject(t, evaluated)
+            continue
+        }
+
+        str, ok := evaluated.(*object.String)
+        if !ok {
+            t.Errorf("object is not String, got = %T (%+v)", evaluated, evaluated)
+            continue
+        }
+        if str.Value != expected {
+            t.Errorf("String has wrong value, expected = %q, got = %q", expected, str.Value)
+        }
+    }
+}
+
+func TestSliceExpressions(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected string
+    }{
+        {"[1, 2, 3, 4][1:3]", "[2, 3]"},
+        {"[1, 2, 3, 4][:-1]", "[1, 2, 3]"},
+        {"[1, 2, 3, 4][2:]", "[3, 4]"},
+        {"[1, 2, 3, 4][:]", "[1, 2, 3, 4]"},
+        {"[1, 2, 3, 4][-2:]", "[3, 4]"},
+        {"[1, 2, 3, 4][3:1]", "[]"},
+        {"[1, 2, 3][1:10]", "[2, 3]"},
+        {"[1, 2, 3][-10:1]", "[1]"},
+        {`"héllo"[1:3]`, "él"},
+        {`"hello"[2:]`, "llo"},
+        {`"hello"[:-2]`, "hel"},
+        {"let xs = [1, 2, 3]; let ys = xs[:]; ys[0] = 9; xs", "[1, 2, 3]"},
+        {"let xs = [1, 2, 3]; xs[-1] = 9; xs", "[1, 2, 9]"},
+        {"5[1:2]", "ERROR: slice operator not supported: INTEGER"},
+        {`[1, 2]["a":]`, "ERROR: slice index must be INTEGER, got STRING"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        if errObj, ok := evaluated.(*object.Error); ok {
+            evaluated = &object.Error{Message: errObj.Message}
+        }
+        if evaluated.Inspect() != tt.expected {
+            t.Errorf("wrong result for %s, expected = %q, got = %q", tt.input, tt.expected, evaluated.Inspect())
+        }
+    }
+}
+
+func TestMemberExpressions(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected interface{}
+    }{
+        {`let person = {"name": "x", "age": 40}; person.age;`, 40},
+        {`let people = [{"address": {"zip": 12345}}]; people[0].address.zip;`, 12345},
+        {`let person = {}; person.age;`, nil},
+        {`let person = {"age": 1}; person.age = 2; person.age;`, 2},
+        {`let person = {"age": 1}; person.age += 41; person["age"];`, 42},
+        {`let person = {}; person.age = 7; person.age;`, 7},
+        {"[1, 2, 3].len();", 3},
+        {`"héllo".len();`, 5},
+        {"[1, 2, 3].push(4).last();", 4},
+        {"let xs = [1, 2]; xs.push(3).len() + xs.len();", 5},
+        {`let counter = {"add": fn(a, b) { a + b }}; counter.add(2, 3);`, 5},
+        {`let h = {"len": fn() { 99 }}; h.len();`, 99},
+        {`let h = {"a": 1}; h.len();`, "argument to `len` not supported, got HASH"},
+        {"[1].name;", "member access not supported: ARRAY.name"},
+        {"5.unknown();", "unknown method: INTEGER.unknown"},
+        {"let x = 1; x.y = 2;", "member access not supported: INTEGER.y"},
+        {`let h = {"f": 1}; h.f();`, "not a function: INTEGER"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        if tt.expected == nil {
+            testNullObject(t, evaluated)
+            continue
+        }
+        testIntegerOrError(t, tt.input, evaluated, tt.expected)
+    }
+}
+
+func TestPipeExpressions(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected int64
+    }{
+        {"[1, 2] |> push(3) |> rest() |> len()", 2},
+        {"[1, 2, 3] |> last()", 3},
+        {"let add = fn(a, b) { a + b }; 1 |> add(2) |> add(3)", 6},
+        {"let double = fn(x) { x * 2 }; 2 + 3 |> double()", 10},
+        {`let h = {"sub": fn(a, b) { a - b }}; 10 |> h.sub(4)`, 6},
+    }
+
+    for _, tt := range tests {
+        testIntegerObject(t, testEval(tt.input), tt.expected)
+    }
+}
+
+func TestStrictIndexing(t *testing.T) {
+    Strict = true
+    defer func() { Strict = false }()
+
+    tests := []struct {
+        input    string
+        expected string
+    }{
+        {"[1, 2, 3][3]", "index out of range: 3"},
+        {"[1, 2, 3][-4]", "index out of range: -4"},
+        {`"abc"[5]`, "index out of range: 5"},
+        {"[1, 2, 3][1:4]", "slice index out of range: 4"},
+        {"[1, 2, 3][-4:]", "slice index out of range: -4"},
+        {"{}.name", "key not found: name"},
+        {`{}["name"]`, "key not found: name"},
+        {`{"a": 1}[2]`, "key not found: 2"},
+    }
+
+    for _, tt := range tests {
+        errObj, ok := testEval(tt.input).(*object.Error)
+        if !ok {
+            t.Errorf("no error object returned for %q", tt.input)
+            continue
+        }
+        if errObj.Message != tt.expected {
+            t.Errorf("wrong error message, expected = %q, got = %q", tt.expected, errObj.Message)
+        }
+    }
+
+    testIntegerObject(t, testEval("[1, 2, 3][-1]"), 3)
+}
+
+func TestMemberAccessMatchesIndexing(t *testing.T) {
+    defer func() { Strict = false }()
+
+    for _, strict := range []bool{false, true} {
+        Strict = strict
+
+        for _, key := range []string{"a", "b"} {
+            member := testEval(`let h = {"a": 1}; h.` + key)
+            index := testEval(`let h = {"a": 1}; h["` + key + `"]`)
+
+            if member.Type() != index.Type() || member.Inspect() != index.Inspect() {
+                t.Errorf("strict = %t, h.%s is %s, but h[%q] is %s", strict, key, member.Inspect(), key, index.Inspect())
+            }
+        }
+    }
+}
+
 func TestArrayLiterals(t *testing.T) {
     input := "[1, 2 + 3, 3 * 3]"
 
@@ -179,6 +354,13 @@ func TestBuiltinFunctions(t *testing.T) {
         {`len("hello world")`, 11},
         {`len(1)`, "argument to `len` not supported, got INTEGER"},
         {`len("one", "two")`, "wrong no. of arguments. got = 2, want = 1"},
+        {`len("héllo")`, 5},
+        {`len("日本語")`, 3},
+        {`bytelen("héllo")`, 6},
+        {`bytelen(1)`, "argument to `bytelen` must be String, got = INTEGER"},
+        {`range(1, 2, 0)`, "step of `range` must not be zero"},
+        {`range("a")`, "arguments to `range` must be Integer, got = STRING"},
+        {`range()`, "wrong no. of arguments. got = 0, want = 1 to 3"},
     }
 
     for _, tt := range tests {
@@ -217,6 +399,32 @@ func TestStringConcatenation(t *testing.T) {
     }
 }
 
+func TestStringInterpolation(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected string
+    }{
+        {`let user = {"name": "Ann"}; let age = 30; "Hello ${user["name"]}, you are ${age + 1}"`, "Hello Ann, you are 31"},
+        {`"${1.5} ${true} ${[1, 2]}"`, "1.5 true [1, 2]"},
+        {`"nested ${"a ${"b"} c"}"`, "nested a b c"},
+        {`"\${literal}"`, "${literal}"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        str, ok := evaluated.(*object.String)
+
+        if !ok {
+            t.Errorf("object is not String, got = %T (%+v)", evaluated, evaluated)
+            continue
+        }
+
+        if str.Value != tt.expected {
+            t.Errorf("String has wrong value, expected = %q, got = %q", tt.expected, str.Value)
+        }
+    }
+}
+
 func TestStringLiteral(t *testing.T) {
     input := `"Hello World"`
     evaluated := testEval(input)
@@ -248,6 +456,61 @@ func TestFunctionApplication(t *testing.T) {
     }
 }
 
+func TestDefaultAndRestParameters(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected interface{}
+    }{
+        {"let f = fn(a, b = 10) { a + b }; f(1);", 11},
+        {"let f = fn(a, b = 10) { a + b }; f(1, 2);", 3},
+        {"let f = fn(a, b = a * 2) { a + b }; f(3);", 9},
+        {"let n = 0; let f = fn(a = n) { a }; n = 5; f();", 5},
+        {"let f = fn(a, ...rest) { len(rest) }; f(1);", 0},
+        {"let f = fn(a, ...rest) { len(rest) }; f(1, 2, 3);", 2},
+        {"let f = fn(a, ...rest) { rest[1] }; f(1, 2, 3);", 3},
+        {"let f = fn(a = 1, ...rest) { a + len(rest) }; f();", 1},
+        {"let f = fn(x) { x }; f();", "wrong number of arguments: missing argument for x"},
+        {"let f = fn(x) { x }; f(1, 2);", "wrong number of arguments: want at most 1, got 2"},
+        {"let f = fn(a = missing) { a }; f();", "identifier not found: missing"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        testIntegerOrError(t, tt.input, evaluated, tt.expected)
+    }
+}
+
+func TestFunctionObjectInspect(t *testing.T) {
+    evaluated := testEval("fn(a, b = 10, ...rest) { a };")
+
+    expected := "fn(a, b = 10, ...rest) {\na\n}"
+    if evaluated.Inspect() != expected {
+        t.Errorf("wrong Inspect(), expected = %q, got = %q", expected, evaluated.Inspect())
+    }
+}
+
+func TestArrowFunctions(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected int64
+    }{
+        {"let double = x => x * 2; double(5);", 10},
+        {"let add = (a, b) => a + b; add(2, 3);", 5},
+        {"let one = () => 1; one();", 1},
+        {"let add = (a, b = 10) => a + b; add(1);", 11},
+        {"let count = (...rest) => len(rest); count(1, 2, 3);", 3},
+        {"let adder = x => y => x + y; adder(2)(3);", 5},
+        {"let apply = fn(f, x) { f(x) }; apply(x => x * x, 7);", 49},
+        {"[1, 2, 3] |> push(4) |> len()", 4},
+        {"match (5) { n if (n > 1) => n * 2, _ => 0 }", 10},
+    }
+
+    for _, tt := range tests {
+        testIntegerObject(t, testEval(tt.input), tt.expected)
+    }
+}
+
 func TestCloser(t *testing.T) {
     input := `
     let newAdder = fn(x) {
@@ -395,6 +658,275 @@ func TestReturnStatements(t *testing.T) {
     }
 }
 
+func TestForStatements(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected interface{}
+    }{
+        {"let sum = 0; for (x in [1, 2, 3, 4]) { sum = sum + x; } sum;", 10},
+        {"let sum = 0; for (i, x in [10, 20, 30]) { sum = sum + i * x; } sum;", 80},
+        {"let sum = 0; for (i in range(5)) { sum = sum + i; } sum;", 10},
+        {"let sum = 0; for (i in range(2, 5)) { sum = sum + i; } sum;", 9},
+        {"let sum = 0; for (i in range(10, 0, -3)) { sum = sum + i; } sum;", 22},
+        {"let sum = 0; for (i in range(0, 10, 0 - 1)) { sum = sum + i; } sum;", 0},
+        {"let n = 0; for (i in range(9223372036854775800, 9223372036854775807, 5)) { n += 1 } n;", 2},
+        {"let n = 0; for (i in range(-9223372036854775807, -9223372036854775800, 5)) { n += 1 } n;", 2},
+        {"let n = 0; for (i in range(-9223372036854775800, -9223372036854775807, -5)) { n += 1 } n;", 2},
+        {"let n = 0; for (i in range(1, 2, 9223372036854775807)) { n += 1 } n;", 1},
+        {"let n = 0; for (x in range(3)) { n += x }; n", 3},
+        {"let n = 0; for (i in range(-9223372036854775808, 9223372036854775807, 9223372036854775807)) { n += 1 } n;", 3},
+        {`let n = 0; for (i, c in "日本語") { n = i; } n;`, 2},
+        {`let sum = 0; for (k, v in {"a": 1, "b": 2}) { sum = sum + v; } sum;`, 3},
+        {"let sum = 0; for (x in [1, 2, 3, 4, 5]) { if (x == 4) { break; } sum = sum + x; } sum;", 6},
+        {"let sum = 0; for (x in [1, 2, 3, 4, 5]) { if (x == 2) { continue; } sum = sum + x; } sum;", 13},
+        {"let sum = 0; for (x in [1, 2]) { for (y in [10, 20]) { if (y == 20) { break; } sum = sum + x * y; } } sum;", 30},
+        {"let f = fn(xs) { for (x in xs) { if (x > 2) { return x; } } }; f([1, 2, 3, 4]);", 3},
+        {"for (x in []) { x }", nil},
+        {"for (x in 5) { x }", "cannot iterate over INTEGER"},
+        {"for (x in [1]) { x + true }", "type mismatch: INTEGER + BOOLEAN"},
+        {"let i = 0; while (true) { i = i + 1; if (i == 5) { break; } } i;", 5},
+        {"let i = 0; let sum = 0; while (i < 5) { i = i + 1; if (i == 2) { continue; } sum = sum + i; } sum;", 13},
+        {"break;", "break outside of loop"},
+        {"let f = fn() { continue; }; f();", "continue outside of loop"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        if tt.expected == nil {
+            if evaluated != nil {
+                t.Errorf("for did not return nil, got = %T (%+v)", evaluated, evaluated)
+            }
+            continue
+        }
+        testIntegerOrError(t, tt.input, evaluated, tt.expected)
+    }
+}
+
+func TestAssignExpressions(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected interface{}
+    }{
+        {"let x = 1; x = 5; x;", 5},
+        {"let x = 1; x = 5;", 5},
+        {"let x = 1; x += 4; x;", 5},
+        {"let x = 10; x -= 4; x;", 6},
+        {"let x = 3; x *= 4; x;", 12},
+        {"let x = 12; x /= 4; x;", 3},
+        {"let a = 0; let b = 0; a = b = 7; a + b;", 14},
+        {"let x = 1; let f = fn() { x = 2; }; f(); x;", 2},
+        {"let x = 1; let f = fn() { let x = 10; x = 20; }; f(); x;", 1},
+        {"let sum = 0; for (x in [1, 2, 3]) { sum += x; } sum;", 6},
+        {"let i = 0; while (i < 10) { i += 1; } i;", 10},
+        {"let xs = [1, 2, 3]; xs[1] = 20; xs[1];", 20},
+        {"let xs = [1, 2, 3]; xs[2] *= 5; xs[2];", 15},
+        {"let xs = [1, 2, 3]; let ys = xs; ys[0] = 9; xs[0];", 9},
+        {`let h = {"a": 1}; h["b"] = 2; h["a"] + h["b"];`, 3},
+        {`let h = {"a": 1}; h["a"] += 10; h["a"];`, 11},
+        {"x = 5;", "cannot assign to undeclared identifier: x"},
+        {"let x = 1; x = y;", "identifier not found: y"},
+        {"let x = 1; x += true;", "type mismatch: INTEGER + BOOLEAN"},
+        {"let xs = [1]; xs[1] = 2;", "index out of range: 1"},
+        {`let h = {}; h[fn(x) { x }] = 1;`, "unusable as hash key: FUNCTION"},
+        {`let s = "abc"; s[0] = "x";`, "index assignment not supported: STRING"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        testIntegerOrError(t, tt.input, evaluated, tt.expected)
+    }
+}
+
+func TestDestructuringLetStatements(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected interface{}
+    }{
+        {"let [a, b] = [1, 2]; a * 10 + b;", 12},
+        {"let [a, b, ...tail] = [1, 2, 3, 4]; len(tail) * 100 + tail[0] * 10 + tail[1];", 234},
+        {"let [a, ...tail] = [1]; len(tail);", 0},
+        {"let [a, [b, c]] = [1, [2, 3]]; a + b + c;", 6},
+        {`let {name, age: years} = {"name": 5, "age": 40}; name + years;`, 45},
+        {`let {address: {zip: [first]}} = {"address": {"zip": [7, 8]}}; first;`, 7},
+        {`let [{x}, {x: y}] = [{"x": 1}, {"x": 2}]; x * 10 + y;`, 12},
+        {`let {"first-name": first} = {"first-name": 3}; first;`, 3},
+        {"let [a, b] = [1]; b;", nil},
+        {`let {missing} = {}; missing;`, nil},
+        {"let [a] = 5;", "cannot destructure INTEGER as ARRAY"},
+        {"let {a} = [1];", "cannot destructure ARRAY as HASH"},
+        {"let [a, {b}] = [1, 2];", "cannot destructure INTEGER as HASH"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        if tt.expected == nil {
+            testNullObject(t, evaluated)
+            continue
+        }
+        testIntegerOrError(t, tt.input, evaluated, tt.expected)
+    }
+}
+
+func TestStrictDestructuring(t *testing.T) {
+    Strict = true
+    defer func() { Strict = false }()
+
+    tests := []struct {
+        input    string
+        expected string
+    }{
+        {"let [a, b] = [1];", "index out of range: 1"},
+        {`let {name, age} = {"name": 1};`, "key not found: age"},
+        {`let {address: {city}} = {"address": {}};`, "key not found: city"},
+    }
+
+    for _, tt := range tests {
+        errObj, ok := testEval(tt.input).(*object.Error)
+        if !ok {
+            t.Errorf("no error object returned for %q", tt.input)
+            continue
+        }
+        if errObj.Message != tt.expected {
+            t.Errorf("wrong error message, expected = %q, got = %q", tt.expected, errObj.Message)
+        }
+    }
+
+    testIntegerObject(t, testEval("let [a, ...rest] = [1]; a;"), 1)
+}
+
+func TestMatchExpressions(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected interface{}
+    }{
+        {"match (1) { 0 => 10, 1 => 20, _ => 30 }", 20},
+        {"match (5) { 0 => 10, 1 => 20, _ => 30 }", 30},
+        {"match (-1) { -1 => 1, _ => 2 }", 1},
+        {"match (2.0) { 2 => 1, _ => 2 }", 1},
+        {`match ("b") { "a" => 1, "b" => 2 }`, 2},
+        {"match (false) { true => 1, false => 2 }", 2},
+        {"match (7) { n => n * 2 }", 14},
+        {"match (7) { n if n > 10 => 1, n if n > 5 => 2, _ => 3 }", 2},
+        {"match ([1, 2, 3]) { [] => 0, [x] => 1, [x, y] => 2, [x, ...rest] => len(rest) }", 2},
+        {"match ([1, 2]) { [a, b] => a + b }", 3},
+        {"match ([1, 2]) { [1, b] => b, _ => 0 }", 2},
+        {"match ([1, 2]) { [a, b, c] => 1, [a, b, ...rest] => len(rest) }", 0},
+        {`match ({"kind": "circle", "r": 3}) { {kind: "square", side} => side, {kind: "circle", r} => r * 2 }`, 6},
+        {`match ({"name": "x"}) { {age} => 1, {name} => 2 }`, 2},
+        {`match ({"point": [1, 2]}) { {point: [x, y]} => x + y }`, 3},
+        {"match (5) { [x] => 1, {x} => 2, _ => 3 }", 3},
+        {"let x = 1; match (5) { x if false => 1, _ => x }", 1},
+        {"match (3) { 1 => 1, 2 => 2 }", "non-exhaustive match: no pattern matches 3"},
+        {"match ([1]) { [x] if x + true => 1 }", "type mismatch: INTEGER + BOOLEAN"},
+        {"match (missing) { _ => 1 }", "identifier not found: missing"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        testIntegerOrError(t, tt.input, evaluated, tt.expected)
+    }
+}
+
+func TestConstStatements(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected interface{}
+    }{
+        {"const x = 5; x;", 5},
+        {"const x = 5; let f = fn() { let x = 10; x }; f() + x;", 15},
+        {"const x = 5; let f = fn(x) { x * 2 }; f(3);", 6},
+        {"const xs = [1, 2]; xs[0] = 9; xs[0];", 9},
+        {"const [a, b] = [1, 2]; a + b;", 3},
+        {"const x = 5; match (1) { x => x }", 1},
+        {"const x = 5; let x = 6;", "cannot redefine constant x"},
+        {"const x = 5; const x = 6;", "cannot redefine constant x"},
+        {"const x = 5; x = 6;", "cannot assign to constant x"},
+        {"const x = 5; x += 1; x;", "cannot assign to constant x"},
+        {"const x = 5; let f = fn() { x = 6; }; f();", "cannot assign to constant x"},
+        {"const [a, ...rest] = [1]; let rest = 2;", "cannot redefine constant rest"},
+        {"const {name} = {}; let [name] = [1];", "cannot redefine constant name"},
+        {"const x = 5; for (x in [1, 2]) { x }", "cannot redefine constant x"},
+        {"let i = 0; while (i < 3) { const x = i; i += 1; } i;", 3},
+        {"let sum = 0; for (v in [1, 2, 3]) { const d = v * 2; sum += d; } sum;", 12},
+        {"let i = 0; while (i < 3) { const x = i; i += 1; x = 5; }", "cannot assign to constant x"},
+        {"const len = 5; len;", 5},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        testIntegerOrError(t, tt.input, evaluated, tt.expected)
+    }
+}
+
+func TestConstErrorPosition(t *testing.T) {
+    evaluated := testEval("const x = 5;\nx = 6;")
+
+    errObj, ok := evaluated.(*object.Error)
+    if !ok {
+        t.Fatalf("no error object returned, got = %T (%+v)", evaluated, evaluated)
+    }
+    if errObj.Pos.Line != 2 || errObj.Pos.Column != 1 {
+        t.Errorf("wrong error position, got = %s", errObj.Pos)
+    }
+}
+
+func TestForStatementsOrder(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected string
+    }{
+        {`let s = ""; for (c in "héllo") { s = c + s; } s;`, "olléh"},
+        {`let keys = ""; for (k in {"b": 2, "a": 1, "c": 3}) { keys = keys + k; } keys;`, "abc"},
+        {`let keys = ""; for (k, v in {3: "c", 1: "a", 2: "b"}) { keys = keys + v; } keys;`, "abc"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        str, ok := evaluated.(*object.String)
+        if !ok {
+            t.Errorf("object is not String. got = %T (%+v)", evaluated, evaluated)
+            continue
+        }
+        if str.Value != tt.expected {
+            t.Errorf("String has wrong value. got = %q, want = %q", str.Value, tt.expected)
+        }
+    }
+}
+
+func TestWhileStatements(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected interface{}
+    }{
+        {"let i = 0; while (i < 5) { i = i + 1; } i;", 5},
+        {"let i = 10; while (i < 5) { i = i + 1; } i;", 10},
+        {"let sum = 0; let i = 0; while (i < 100000) { i = i + 1; sum = sum + i; } sum;", 5000050000},
+        {"let f = fn() { let i = 0; while (true) { i = i + 1; if (i == 3) { return i * 10; } } }; f();", 30},
+        {"while (false) { 1 }", nil},
+        {"let i = 0; while (i < 3) { i = i + 1; i + true; } i;", "type mismatch: INTEGER + BOOLEAN"},
+        {"while (undefined) { 1 }", "identifier not found: undefined"},
+        {"let i = 0; while (i < 2) { let j = i; i += 1; } j;", "identifier not found: j"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        if tt.expected == nil {
+            if evaluated != nil {
+                t.Errorf("while did not return nil, got = %T (%+v)", evaluated, evaluated)
+            }
+            continue
+        }
+        testIntegerOrError(t, tt.input, evaluated, tt.expected)
+    }
+}
+
 func TestIfElseExpressions(t *testing.T) {
     tests := []struct {
         input    string
@@ -407,6 +939,10 @@ func TestIfElseExpressions(t *testing.T) {
         {"if (1 > 2) { 10 }", nil},
         {"if (1 > 2) { 10 } else { 20 }", 20},
         {"if (1 < 2) { 10 } else { 20 }", 10},
+        {"if (1 > 2) { 10 } else if (2 > 1) { 20 } else { 30 }", 20},
+        {"if (1 > 2) { 10 } else if (2 > 3) { 20 } else { 30 }", 30},
+        {"if (1 > 2) { 10 } else if (2 > 3) { 20 }", nil},
+        {"let x = 3; if (x == 1) { 10 } else if (x == 2) { 20 } else if (x == 3) { 30 } else { 40 }", 30},
     }
 
     for _, tt := range tests {
@@ -440,6 +976,27 @@ func TestEvalBangOperator(t *testing.T) {
     }
 }
 
+func TestLogicalShortCircuit(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected bool
+    }{
+        {"false && undefined", false},
+        {"true || undefined", true},
+        {"let calls = fn() { missing() }; false && calls()", false},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        testBooleanObject(t, evaluated, tt.expected)
+    }
+
+    evaluated := testEval("true && undefined")
+    if _, ok := evaluated.(*object.Error); !ok {
+        t.Errorf("right operand was not evaluated, got = %T (%+v)", evaluated, evaluated)
+    }
+}
+
 // for integer expressions
 func TestEvalIntegerExpression(t *testing.T) {
     tests := []struct {
@@ -469,6 +1026,90 @@ func TestEvalIntegerExpression(t *testing.T) {
     }
 }
 
+func TestEvalFloatExpression(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected float64
+    }{
+        {"3.5", 3.5},
+        {"-2.5", -2.5},
+        {"1.5 + 1.5", 3},
+        {"1 + 0.5", 1.5},
+        {"0.5 * 4", 2},
+        {"7 / 2.0", 3.5},
+        {"10 - 2.5e1", -15},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        testFloatObject(t, evaluated, tt.expected)
+    }
+}
+
+func TestEvalArithmeticAndBitwiseOperators(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected interface{}
+    }{
+        {"7 % 3", 1},
+        {"-7 % 3", -1},
+        {"2 ** 10", 1024},
+        {"2 ** 3 ** 2", 512},
+        {"-2 ** 2", -4},
+        {"(-2) ** 3", -8},
+        {"5 ** 0", 1},
+        {"2 ** -1", 0.5},
+        {"7.5 % 2", 1.5},
+        {"2.0 ** 3", 8.0},
+        {"6 & 3", 2},
+        {"6 | 3", 7},
+        {"6 ^ 3", 5},
+        {"~5", -6},
+        {"1 << 10", 1024},
+        {"-16 >> 2", -4},
+        {"1 | 2 & 3", 3},
+        {"let flags = 0; flags = flags | 4; flags & 4 == 4", true},
+        {"5 % 0", "modulo by zero"},
+        {"5.0 % 0", "modulo by zero"},
+        {"5 / 0", "division by zero"},
+        {"1 << -1", "invalid shift count: -1, must be between 0 and 63"},
+        {"1 >> 64", "invalid shift count: 64, must be between 0 and 63"},
+        {"1.5 & 1", "unknown operator: FLOAT & INTEGER"},
+        {"~true", "unknown operator: ~BOOLEAN"},
+        {`"a" % "b"`, "unknown operator: STRING % STRING"},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+
+        switch expected := tt.expected.(type) {
+        case float64:
+            testFloatObject(t, evaluated, expected)
+        case bool:
+            testBooleanObject(t, evaluated, expected)
+        default:
+            testIntegerOrError(t, tt.input, evaluated, expected)
+        }
+    }
+}
+
+func TestEvalMixedNumberComparison(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected bool
+    }{
+        {"1 == 1.0", true},
+        {"1.5 > 1", true},
+        {"2 < 1.5", false},
+        {"0.1 != 0.1", false},
+    }
+
+    for _, tt := range tests {
+        evaluated := testEval(tt.input)
+        testBooleanObject(t, evaluated, tt.expected)
+    }
+}
+
 // for boolean expressions
 func TestEvalBooleanExpression(t *testing.T) {
     tests := []struct {
@@ -494,6 +1135,18 @@ func TestEvalBooleanExpression(t *testing.T) {
         {"(1 < 2) == false", false},
         {"(1 > 2) == true", false},
         {"(1 > 2) == false", true},
+        {"1 <= 2", true},
+        {"2 <= 2", true},
+        {"3 <= 2", false},
+        {"1 >= 2", false},
+        {"2 >= 2", true},
+        {"2.5 >= 2", true},
+        {"true && true", true},
+        {"true && false", false},
+        {"false || true", true},
+        {"false || false", false},
+        {"1 && 0", true},
+        {"1 < 2 && 2 < 3", true},
     }
 
     for _, tt := range tests {
@@ -519,6 +1172,23 @@ func testIntegerObject(t *testing.T, evaluated object.Object, expected int64) bo
     return true
 }
 
+// for float expressions
+func testFloatObject(t *testing.T, evaluated object.Object, expected float64) bool {
+    result, ok := evaluated.(*object.Float)
+
+    if !ok {
+        t.Errorf("object is not Float. got=%T (%+v)", evaluated, evaluated)
+        return false
+    }
+
+    if result.Value != expected {
+        t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+        return false
+    }
+
+    return true
+}
+
 // for boolean expressions
 func testBooleanObject(t *testing.T, evaluated object.Object, expected bool) bool {
     result, ok := evaluated.(*object.Boolean)
@@ -536,6 +1206,28 @@ func testBooleanObject(t *testing.T, evaluated object.Object, expected bool) boo
     return true
 }
 
+// expects an Integer for an int and an Error with that message for a string.
+func testIntegerOrError(t *testing.T, input string, evaluated object.Object, expected interface{}) bool {
+    switch expected := expected.(type) {
+    case int:
+        return testIntegerObject(t, evaluated, int64(expected))
+    case string:
+        errObj, ok := evaluated.(*object.Error)
+        if !ok {
+            t.Errorf("no error object returned for %s, got = %T (%+v)", input, evaluated, evaluated)
+            return false
+        }
+        if errObj.Message != expected {
+            t.Errorf("wrong error message for %s, expected = %q, got = %q", input, expected, errObj.Message)
+            return false
+        }
+        return true
+    default:
+        t.Errorf("type of expected value not handled: %T", expected)
+        return false
+    }
+}
+
 func testNullObject(t *testing.T, evaluated object.Object) bool {
     if evaluated != NULL {
         t.Errorf("object is not NULL, got = %T (%+v)", evaluated, evaluated)
@@ -546,10 +1238,35 @@ func testNullObject(t *testing.T, evaluated object.Object) bool {
 }
 
 func testEval(input string) object.Object {
-    l := lexer.New(input)
+    l := lexer.New("", input)
     p := parser.New(l)
     program := p.ParseProgram()
     env := object.NewEnvironment()
 
     return Eval(program, env)
 }
+
+func TestErrorPositions(t *testing.T) {
+    input := `let f = fn(x) {
+    x + true;
+};
+f(1);`
+
+    l := lexer.New("test.mkl", input)
+    p := parser.New(l)
+    evaluated := Eval(p.ParseProgram(), object.NewEnvironment())
+
+    errObj, ok := evaluated.(*object.Error)
+    if !ok {
+        t.Fatalf("no error object returned, got = %T (%+v)", evaluated, evaluated)
+    }
+
+    if errObj.Pos.String() != "test.mkl:2:5" {
+        t.Errorf("wrong error position, expected = %q, got = %q", "test.mkl:2:5", errObj.Pos)
+    }
+
+    expected := "ERROR: test.mkl:2:5: type mismatch: INTEGER + BOOLEAN"
+    if errObj.Inspect() != expected {
+        t.Errorf("wrong Inspect(), expected = %q, got = %q", expected, errObj.Inspect())
+    }
+}
@@ -1,28 +1,83 @@
 package lexer
 
-import "monkeylang/token"
+import (
+	"fmt"
+	"io"
+	"monkeylang/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
-// our lexer has the input string(which the source code writter by the user).
+// how many bytes we read from the input at once.
+const readSize = 4096
+
+// our lexer reads the input (which is the source code written by the user) piece by piece.
 type Lexer struct {
-	input        string
-	position     int  // current position
-	readPosition int  // current reading position (after current char)
-	ch           byte // current character
+	filename     string    // name of the source file, used in the positions of the tokens
+	reader       io.Reader // where the input comes from, nil once everything has been read
+	buf          []byte    // the part of the input which we have read but not used up yet
+	bufOffset    int       // the offset of buf[0] in the input
+	position     int       // current position
+	readPosition int       // current reading position (after current char)
+	ch           rune      // current character, the input is decoded as UTF-8
+	line         int       // line of the current character
+	column       int       // column of the current character, counted in characters and not in bytes
+
+	pending *token.Token // an ILLEGAL token found while reading trailing comments, returned by the next call to NextToken
+	errors  []*Error     // errors found in the input, every ILLEGAL token has one
+
+	// one entry for every ${ we are inside of, it counts the { opened in the embedded expression,
+	// so we know which } goes back to the string.
+	interpolations []int
 }
 
-// basically a constructor of our lexer.
-func New(input string) *Lexer {
-	l := &Lexer{input: input}
+// basically a constructor of our lexer, the filename can be empty if the input does not come from a file.
+func New(filename, input string) *Lexer {
+	return NewReader(filename, strings.NewReader(input))
+}
+
+// creates a lexer which reads the input while it is lexing, so big files never have to be in memory at once.
+func NewReader(filename string, r io.Reader) *Lexer {
+	l := &Lexer{filename: filename, reader: r, line: 1}
 	l.readChar()
 	return l
 }
 
-// returns the next token in the input source code.
+// returns the next token in the input source code, together with the comments around it.
 func (l *Lexer) NextToken() token.Token {
+	if l.pending != nil {
+		tok := *l.pending
+		l.pending = nil
+		return tok
+	}
+
+	// everything before the current character belongs to tokens we have already returned.
+	l.discard()
+
+	// skips all whitespaces and comments before the token, the comments are kept as leading trivia.
+	leading, illegal := l.readLeadingComments()
+	if illegal != nil {
+		return *illegal
+	}
+
+	tok := l.readToken()
+	tok.Leading = leading
+
+	if tok.Type != token.EOF {
+		tok.Trailing = l.readTrailingComments()
+	}
+
+	return tok
+}
+
+// reads the token at the current character, whitespaces and comments have to be skipped already.
+func (l *Lexer) readToken() token.Token {
 	var tok token.Token
 
-	// skips all types of whitespaces in our source code before giving the next token.
-	l.skipWhiteSpace()
+	// the token starts at the current character.
+	pos := l.currentPosition()
 
 	// checks the type of the current character and returns the corresponding token.
 	switch l.ch {
@@ -32,13 +87,29 @@ func (l *Lexer) NextToken() token.Token {
 			ch := l.ch
 			l.readChar()
 			tok = token.Token{Type: token.EQ, Literal: string(ch) + string(l.ch)} // this is the equality operator.
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: string(ch) + string(l.ch)} // this separates a pattern from its result.
 		} else { // otherwise, returns the token for '='.
 			tok = newToken(token.ASSIGN, l.ch) // this is the assignment operator.
 		}
 	case '+':
-		tok = newToken(token.PLUS, l.ch) // normal stuff
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.PLUS_ASSIGN, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.PLUS, l.ch) // normal stuff
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch) // normal stuff
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.MINUS_ASSIGN, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.MINUS, l.ch) // normal stuff
+		}
 	case '!':
 		if l.peekChar() == '=' { // checks for the not equal operator.
 			ch := l.ch
@@ -48,13 +119,75 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch) // checks for the "bang" operator.
 		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch) // normal stuff
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.SLASH_ASSIGN, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.SLASH, l.ch) // normal stuff
+		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch) // normal stuff
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.ASTERISK_ASSIGN, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '*' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.POWER, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.ASTERISK, l.ch) // normal stuff
+		}
 	case '<':
-		tok = newToken(token.LT, l.ch) // normal stuff
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.LT_EQ, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '<' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.SHL, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.LT, l.ch) // normal stuff
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch) // normal stuff
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.GT_EQ, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.SHR, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.GT, l.ch) // normal stuff
+		}
+	case '&':
+		if l.peekChar() == '&' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.AND, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.BIT_AND, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.OR, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.PIPE, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.BIT_OR, l.ch)
+		}
+	case '^':
+		tok = newToken(token.BIT_XOR, l.ch)
+	case '~':
+		tok = newToken(token.BIT_NOT, l.ch)
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch) // normal stuff
 	case ',':
@@ -64,9 +197,21 @@ func (l *Lexer) NextToken() token.Token {
 	case ')':
 		tok = newToken(token.RPAREN, l.ch) // normal stuff
 	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1] += 1
+		}
 		tok = newToken(token.LBRACE, l.ch) // normal stuff
 	case '}':
-		tok = newToken(token.RBRACE, l.ch) // normal stuff
+		if n := len(l.interpolations); n > 0 && l.interpolations[n-1] == 0 {
+			// this closes a ${, so we continue with the rest of the string.
+			l.interpolations = l.interpolations[:n-1]
+			tok = l.readStringToken(pos, token.INTERP_END, token.INTERP_MIDDLE)
+		} else {
+			if n > 0 {
+				l.interpolations[n-1] -= 1
+			}
+			tok = newToken(token.RBRACE, l.ch) // normal stuff
+		}
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
@@ -76,42 +221,261 @@ func (l *Lexer) NextToken() token.Token {
 	case 0: // end of file
 		tok.Literal = ""
 		tok.Type = token.EOF
+		tok.Pos, tok.End = pos, pos // there is nothing left to read, so we do not move.
+		return tok
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok = l.readStringToken(pos, token.STRING, token.INTERP_START)
+	case '`':
+		literal, ok := l.readRawString()
+		if ok {
+			tok.Type = token.STRING
+			tok.Literal = literal
+		} else {
+			l.error(pos, "unterminated raw string literal")
+			tok.Type = token.ILLEGAL
+			tok.Literal = l.text(pos.Offset, l.position)
+		}
+	case '.':
+		if l.peekChar() == '.' && l.peekSecondChar() == '.' {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = newToken(token.DOT, l.ch) // member access, e.g. person.name
+		}
 	default: // checks if the character is a letter or a digit.
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos, tok.End = pos, l.currentPosition()
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Literal, tok.Type = l.readNumber()
+			tok.Pos, tok.End = pos, l.currentPosition()
 			return tok
 		} else {
+			l.error(pos, "illegal character %q", l.ch)
 			tok = newToken(token.ILLEGAL, l.ch) // illegal stuff.
 		}
 	}
 	l.readChar() // moves to the next character.
-	return tok   // returns the token.
+	tok.Pos, tok.End = pos, l.currentPosition()
+	return tok // returns the token.
+}
+
+// returns the position of the current character.
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
+
+// Error is a problem in the input found by the lexer, like an unterminated string.
+type Error struct {
+	Pos     token.Position // where the problem starts
+	End     token.Position // position immediately after the problem
+	Message string
+}
+
+// formats the error as position: message.
+func (e *Error) String() string {
+	return e.Pos.String() + ": " + e.Message
 }
 
-func (l *Lexer) readString() string {
-	position := l.position + 1
+func (e *Error) Error() string {
+	return e.String()
+}
+
+// returns the errors found so far.
+func (l *Lexer) Errors() []*Error {
+	return l.errors
+}
+
+// records an error from the given position up to and including the current character.
+func (l *Lexer) error(pos token.Position, format string, a ...interface{}) {
+	end := l.currentPosition()
+	if l.ch != 0 {
+		end.Offset = l.readPosition
+		end.Column += 1
+	}
+
+	l.errors = append(l.errors, &Error{Pos: pos, End: end, Message: fmt.Sprintf(format, a...)})
+}
+
+// the ways in which reading a part of a string can stop.
+const (
+	stringClosed       = iota // at the closing quote
+	stringInterpolated        // at the { of a ${
+	stringUnterminated        // at the end of the input
+)
+
+// reads the string after the current " or }, the token has the closedType if the string ends
+// and the interpolatedType if an embedded ${ expression starts.
+func (l *Lexer) readStringToken(pos token.Position, closedType, interpolatedType token.TokenType) token.Token {
+	literal, end := l.readString()
+
+	switch end {
+	case stringClosed:
+		return token.Token{Type: closedType, Literal: literal}
+	case stringInterpolated:
+		l.interpolations = append(l.interpolations, 0)
+		return token.Token{Type: interpolatedType, Literal: literal}
+	default:
+		// the string runs until the end of the input, so we return what we have read as an ILLEGAL token.
+		l.error(pos, "unterminated string literal")
+		return token.Token{Type: token.ILLEGAL, Literal: l.text(pos.Offset, l.position)}
+	}
+}
+
+// reads a string literal and replaces the escape sequences, until the closing quote or the start of a ${.
+func (l *Lexer) readString() (string, int) {
+	var out strings.Builder
 
 	for {
 		l.readChar()
 
-		if l.ch == '"' || l.ch == 0 {
-			break
+		switch l.ch {
+		case '"':
+			return out.String(), stringClosed
+		case 0:
+			return out.String(), stringUnterminated
+		case '$':
+			if l.peekChar() != '{' {
+				out.WriteRune(l.ch)
+				continue
+			}
+			l.readChar()
+			return out.String(), stringInterpolated
+		case '\\':
+			l.readEscape(&out)
+		default:
+			out.WriteRune(l.ch)
 		}
 	}
+}
 
-	return l.input[position:l.position]
+// reads a `raw string`, which can span lines and has no escape sequences. if a line break follows
+// the opening backtick the string is a block: the line break and the indentation the lines have in
+// common are removed, so the string can be indented like the code around it.
+// the boolean is false if the closing backtick is missing.
+func (l *Lexer) readRawString() (string, bool) {
+	position := l.readPosition
+
+	for {
+		l.readChar()
+
+		switch l.ch {
+		case '`':
+			literal := l.text(position, l.position)
+			if strings.HasPrefix(literal, "\n") {
+				literal = dedent(literal[1:])
+			}
+			return literal, true
+		case 0:
+			return "", false
+		}
+	}
+}
+
+// removes the indentation which all non-blank lines have in common. blank lines become empty,
+// so the indentation before the closing backtick does not end up in the string.
+func dedent(s string) string {
+	lines := strings.Split(s, "\n")
+
+	indent := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimLeft(line, " \t") == "" {
+			continue
+		}
+
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indent = lineIndent
+			first = false
+			continue
+		}
+
+		// keep the part of the indentation both lines start with.
+		i := 0
+		for i < len(indent) && i < len(lineIndent) && indent[i] == lineIndent[i] {
+			i++
+		}
+		indent = indent[:i]
+	}
+
+	for i, line := range lines {
+		if strings.TrimLeft(line, " \t") == "" {
+			lines[i] = ""
+		} else {
+			lines[i] = line[len(indent):]
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// reads the escape sequence starting at the current backslash and writes the character it stands for.
+func (l *Lexer) readEscape(out *strings.Builder) {
+	pos := l.currentPosition()
+	l.readChar()
+
+	switch l.ch {
+	case '"':
+		out.WriteByte('"')
+	case '\\':
+		out.WriteByte('\\')
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '$':
+		out.WriteByte('$')
+	case 'u':
+		l.readUnicodeEscape(pos, out)
+	case 0:
+		// the missing closing quote is reported by readString.
+	default:
+		l.error(pos, "unknown escape sequence \\%c", l.ch)
+	}
+}
+
+// reads the {...} part of a \u{...} escape, the current character is the 'u'.
+func (l *Lexer) readUnicodeEscape(pos token.Position, out *strings.Builder) {
+	if l.peekChar() != '{' {
+		l.error(pos, "expected { after \\u")
+		return
+	}
+	l.readChar()
+
+	position := l.readPosition
+	for l.peekChar() != '}' && l.peekChar() != '"' && l.peekChar() != 0 {
+		l.readChar()
+	}
+	digits := l.text(position, l.readPosition)
+
+	if l.peekChar() != '}' {
+		l.error(pos, "unterminated unicode escape \\u{%s", digits)
+		return
+	}
+	l.readChar()
+
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(value)) {
+		l.error(pos, "invalid unicode escape \\u{%s}", digits)
+		return
+	}
+
+	out.WriteRune(rune(value))
 }
 
 // creates a new token with the given type and literal.
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
@@ -121,46 +485,161 @@ func (l *Lexer) readIdentifier() string {
 	for isLetter(l.ch) { // checks that the character is a letter.
 		l.readChar() // advances our position in the input string.
 	}
-	return l.input[position:l.position] // returns the identifier as a string.
+	return l.text(position, l.position) // returns the identifier as a string.
 }
 
-// returns the number as string.
-func (l *Lexer) readNumber() string {
+// returns the number as string, and whether it is an INT or a FLOAT.
+// digits can be separated by underscores, the parser checks that they are used correctly.
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position // save the position of the first digit.
-	for isDigit(l.ch) {
+	var tokenType token.TokenType = token.INT
+
+	// hex (0xff), binary (0b1010) and octal (0o17) integers.
+	if l.ch == '0' && strings.ContainsRune("xXbBoO", l.peekChar()) {
+		l.readChar()
+		l.readChar()
+
+		// we also read digits which are too big for the base, so the parser can report them.
+		for isHexDigit(l.ch) || l.ch == '_' {
+			l.readChar()
+		}
+
+		return l.text(position, l.position), tokenType
+	}
+
+	l.readDigits()
+
+	// a fraction, the dot only belongs to the number if a digit follows it.
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+
+	// an exponent like e10, e+10 or e-10.
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekChar()
+		if isDigit(next) || (next == '+' || next == '-') && isDigit(l.peekSecondChar()) {
+			tokenType = token.FLOAT
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			l.readDigits()
+		}
+	}
+
+	return l.text(position, l.position), tokenType // return the number as a string.
+}
+
+// advances our position until the current character is not a digit or an underscore.
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
 		l.readChar() // advance our position in the input string.
 	}
-	return l.input[position:l.position] // return the number as a string.
 }
 
 // readChar advances our position in the input source code string
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0 // end of file, 0 is ASCII for NULL
-	} else {
-		l.ch = l.input[l.readPosition]
+	// a new line starts after every '\n'.
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+
+	// at the end of file the character is 0, which is ASCII for NULL.
+	var width int
+	l.ch, width = l.decode(l.readPosition)
+
+	// once we have moved past the last character we stay at the end of the input.
+	if width == 0 && l.column > 0 && l.position == l.readPosition {
+		return
 	}
 
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += width
+	l.column += 1
 }
 
 // used to peek at the next character without advancing our position in the input.
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+func (l *Lexer) peekChar() rune {
+	ch, _ := l.decode(l.readPosition)
+	return ch
+}
+
+// peeks at the character after the next one.
+func (l *Lexer) peekSecondChar() rune {
+	_, width := l.decode(l.readPosition)
+	if width == 0 {
 		return 0
-	} else {
-		return l.input[l.readPosition]
 	}
+
+	ch, _ := l.decode(l.readPosition + width)
+	return ch
+}
+
+// decodes the character at the given offset of the input and returns it with its size in bytes.
+// a character can take up to 4 bytes, invalid UTF-8 is read as utf8.RuneError one byte at a time.
+// at the end of the input the character is 0 and the size is 0.
+func (l *Lexer) decode(offset int) (rune, int) {
+	l.fill(offset + utf8.UTFMax)
+
+	i := offset - l.bufOffset
+	if i >= len(l.buf) {
+		return 0, 0
+	}
+
+	return utf8.DecodeRune(l.buf[i:])
+}
+
+// reads from the input until the buffer reaches the given offset, or the input ends.
+func (l *Lexer) fill(offset int) {
+	for l.reader != nil && l.bufOffset+len(l.buf) < offset {
+		if len(l.buf) == cap(l.buf) {
+			buf := make([]byte, len(l.buf), 2*cap(l.buf)+readSize)
+			copy(buf, l.buf)
+			l.buf = buf
+		}
+
+		n, err := l.reader.Read(l.buf[len(l.buf):cap(l.buf)])
+		l.buf = l.buf[:len(l.buf)+n]
+
+		if err != nil {
+			if err != io.EOF {
+				l.error(l.currentPosition(), "could not read input: %s", err)
+			}
+			l.reader = nil
+		}
+	}
+}
+
+// drops the part of the buffer before the current character, we only do it once that is
+// most of the buffer so we do not move the bytes around for every token.
+func (l *Lexer) discard() {
+	n := l.position - l.bufOffset
+	if n < readSize || n < len(l.buf)/2 {
+		return
+	}
+
+	l.buf = append(l.buf[:0], l.buf[n:]...)
+	l.bufOffset += n
+}
+
+// returns the input between the two offsets, which must not have been discarded.
+func (l *Lexer) text(start, end int) string {
+	return string(l.buf[start-l.bufOffset : end-l.bufOffset])
 }
 
 // the following two functions are helper functions to check if a character is a letter or a digit.
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
 
 // skips all types of whitespaces in our source code.
 func (l *Lexer) skipWhiteSpace() {
@@ -168,3 +647,101 @@ func (l *Lexer) skipWhiteSpace() {
 		l.readChar()
 	}
 }
+
+// checks if a comment starts at the current character.
+func (l *Lexer) atComment() bool {
+	return l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*')
+}
+
+// skips whitespaces and returns the comments in between, an unterminated block comment is returned as an ILLEGAL token.
+func (l *Lexer) readLeadingComments() ([]token.Comment, *token.Token) {
+	var comments []token.Comment
+
+	for {
+		l.skipWhiteSpace()
+
+		if !l.atComment() {
+			return comments, nil
+		}
+
+		comment, terminated := l.readComment()
+		if !terminated {
+			return comments, l.unterminatedComment(comment)
+		}
+		comments = append(comments, comment)
+	}
+}
+
+// returns the comments which follow the last token on the same line.
+func (l *Lexer) readTrailingComments() []token.Comment {
+	var comments []token.Comment
+
+	for {
+		for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' {
+			l.readChar()
+		}
+
+		if !l.atComment() {
+			return comments
+		}
+
+		comment, terminated := l.readComment()
+		if !terminated {
+			// the comment runs until the end of the input, so the next token is the error.
+			l.pending = l.unterminatedComment(comment)
+			return comments
+		}
+		comments = append(comments, comment)
+
+		// a line comment ends the line, so nothing after it can be trailing.
+		if comment.Text[1] == '/' {
+			return comments
+		}
+	}
+}
+
+// reads a // or /* */ comment starting at the current character, block comments can be nested.
+// the boolean is false if a block comment is not closed before the end of the input.
+func (l *Lexer) readComment() (token.Comment, bool) {
+	pos := l.currentPosition()
+	position := l.position
+	terminated := true
+
+	if l.peekChar() == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+	} else {
+		// skip the opening /*
+		l.readChar()
+		l.readChar()
+
+		depth := 1
+		for depth > 0 {
+			switch {
+			case l.ch == 0:
+				terminated = false
+				depth = 0
+			case l.ch == '/' && l.peekChar() == '*':
+				depth += 1
+				l.readChar()
+				l.readChar()
+			case l.ch == '*' && l.peekChar() == '/':
+				depth -= 1
+				l.readChar()
+				l.readChar()
+			default:
+				l.readChar()
+			}
+		}
+	}
+
+	comment := token.Comment{Text: l.text(position, l.position), Pos: pos, End: l.currentPosition()}
+	return comment, terminated
+}
+
+// reports a block comment which is never closed and creates the ILLEGAL token for it.
+func (l *Lexer) unterminatedComment(comment token.Comment) *token.Token {
+	l.error(comment.Pos, "unterminated block comment")
+	return &token.Token{Type: token.ILLEGAL, Literal: comment.Text, Pos: comment.Pos, End: comment.End}
+}
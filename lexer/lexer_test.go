@@ -1,7 +1,12 @@
 package lexer
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"monkeylang/token"
 )
@@ -13,7 +18,7 @@ func TestNextToken(t *testing.T) {
 	x + y;
 	};
 	let result = add(five, ten);
-	!-/*5;
+	!-/ *5;
 	5 < 10 > 5;
 	if (5 < 10) {
 		return true;
@@ -22,6 +27,12 @@ func TestNextToken(t *testing.T) {
 	}
 	10 == 10;
 	10 != 9;
+	a <= b >= c && d || e;
+	x += 1 -= y *= 2 /= z;
+	fn(...rest) {};
+	match (x) { _ => 1 };
+	xs |> f();
+	a % b ** c & d | e ^ ~f << g >> h;
     "foobar"
     "foo bar"
     [1, 2];
@@ -104,6 +115,67 @@ func TestNextToken(t *testing.T) {
 		{token.NOT_EQ, "!="},
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "c"},
+		{token.AND, "&&"},
+		{token.IDENT, "d"},
+		{token.OR, "||"},
+		{token.IDENT, "e"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "1"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.IDENT, "y"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.INT, "2"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.IDENT, "z"},
+		{token.SEMICOLON, ";"},
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "rest"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, ";"},
+		{token.MATCH, "match"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "_"},
+		{token.ARROW, "=>"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "xs"},
+		{token.PIPE, "|>"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "b"},
+		{token.POWER, "**"},
+		{token.IDENT, "c"},
+		{token.BIT_AND, "&"},
+		{token.IDENT, "d"},
+		{token.BIT_OR, "|"},
+		{token.IDENT, "e"},
+		{token.BIT_XOR, "^"},
+		{token.BIT_NOT, "~"},
+		{token.IDENT, "f"},
+		{token.SHL, "<<"},
+		{token.IDENT, "g"},
+		{token.SHR, ">>"},
+		{token.IDENT, "h"},
+		{token.SEMICOLON, ";"},
 		{token.STRING, "foobar"},
 		{token.STRING, "foo bar"},
 		{token.LBRACKET, "["},
@@ -119,7 +191,7 @@ func TestNextToken(t *testing.T) {
 		{token.RBRACE, "}"},
 		{token.EOF, ""},
 	}
-	l := New(input)
+	l := New("", input)
 	for i, tt := range tests {
 		tok := l.NextToken() // this is the loop which reads the input
 
@@ -133,3 +205,405 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5;\n  x + \"ab\""
+
+	tests := []struct {
+		expectedType      token.TokenType
+		expectedLine      int
+		expectedColumn    int
+		expectedOffset    int
+		expectedEndOffset int
+	}{
+		{token.LET, 1, 1, 0, 3},
+		{token.IDENT, 1, 5, 4, 5},
+		{token.ASSIGN, 1, 7, 6, 7},
+		{token.INT, 1, 9, 8, 9},
+		{token.SEMICOLON, 1, 10, 9, 10},
+		{token.IDENT, 2, 3, 13, 14},
+		{token.PLUS, 2, 5, 15, 16},
+		{token.STRING, 2, 7, 17, 21},
+		{token.EOF, 2, 11, 21, 21},
+	}
+
+	l := New("test.mkl", input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Pos.Filename != "test.mkl" {
+			t.Errorf("tests[%d] - filename wrong. expected=%q, got=%q", i, "test.mkl", tok.Pos.Filename)
+		}
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - line:column wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Pos.Line, tok.Pos.Column)
+		}
+		if tok.Pos.Offset != tt.expectedOffset {
+			t.Errorf("tests[%d] - offset wrong. expected=%d, got=%d", i, tt.expectedOffset, tok.Pos.Offset)
+		}
+		if tok.End.Offset != tt.expectedEndOffset {
+			t.Errorf("tests[%d] - end offset wrong. expected=%d, got=%d", i, tt.expectedEndOffset, tok.End.Offset)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+let x = 5; // trailing comment
+/* block /* nested */ comment */ x / 2 /* after */
+/* unterminated`
+
+	tests := []struct {
+		expectedType     token.TokenType
+		expectedLiteral  string
+		expectedLeading  []string
+		expectedTrailing []string
+	}{
+		{token.LET, "let", []string{"// leading comment"}, nil},
+		{token.IDENT, "x", nil, nil},
+		{token.ASSIGN, "=", nil, nil},
+		{token.INT, "5", nil, nil},
+		{token.SEMICOLON, ";", nil, []string{"// trailing comment"}},
+		{token.IDENT, "x", []string{"/* block /* nested */ comment */"}, nil},
+		{token.SLASH, "/", nil, nil},
+		{token.INT, "2", nil, []string{"/* after */"}},
+		{token.ILLEGAL, "/* unterminated", nil, nil},
+		{token.EOF, "", nil, nil},
+	}
+
+	l := New("", input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		testComments(t, i, "leading", tok.Leading, tt.expectedLeading)
+		testComments(t, i, "trailing", tok.Trailing, tt.expectedTrailing)
+	}
+}
+
+func testComments(t *testing.T, i int, kind string, comments []token.Comment, expected []string) {
+	if len(comments) != len(expected) {
+		t.Errorf("tests[%d] - wrong number of %s comments. expected=%d, got=%d", i, kind, len(expected), len(comments))
+		return
+	}
+
+	for j, comment := range comments {
+		if comment.Text != expected[j] {
+			t.Errorf("tests[%d] - %s comment wrong. expected=%q, got=%q", i, kind, expected[j], comment.Text)
+		}
+	}
+}
+
+func TestFloatLiterals(t *testing.T) {
+	input := `3.14 0.5 1e10 2.5E-3 7e+2 10 1.foo 3e`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "0.5"},
+		{token.FLOAT, "1e10"},
+		{token.FLOAT, "2.5E-3"},
+		{token.FLOAT, "7e+2"},
+		{token.INT, "10"},
+		{token.INT, "1"},
+		{token.DOT, "."},
+		{token.IDENT, "foo"},
+		{token.INT, "3"},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+
+	l := New("", input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"say \"hi\""`, `say "hi"`},
+		{`"a\\b"`, `a\b`},
+		{`"line\nbreak"`, "line\nbreak"},
+		{`"tab\there\r"`, "tab\there\r"},
+		{`"\u{48}\u{e9}\u{1F600}"`, "Hé😀"},
+	}
+
+	for _, tt := range tests {
+		l := New("", tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != token.STRING {
+			t.Fatalf("tokentype wrong for %s. expected=%q, got=%q", tt.input, token.STRING, tok.Type)
+		}
+		if tok.Literal != tt.expected {
+			t.Errorf("literal wrong for %s. expected=%q, got=%q", tt.input, tt.expected, tok.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("unexpected errors for %s: %v", tt.input, l.Errors())
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedType  token.TokenType
+		expectedError string
+	}{
+		{"let s = \"abc", token.ILLEGAL, "1:9: unterminated string literal"},
+		{`"\q"`, token.STRING, `1:2: unknown escape sequence \q`},
+		{`"\u{110000}"`, token.STRING, `1:2: invalid unicode escape \u{110000}`},
+		{`"\u{41"`, token.STRING, `1:2: unterminated unicode escape \u{41`},
+		{`"\u41"`, token.STRING, `1:2: expected { after \u`},
+		{"#", token.ILLEGAL, "1:1: illegal character '#'"},
+		{"x /* never closed", token.ILLEGAL, "1:3: unterminated block comment"},
+	}
+
+	for _, tt := range tests {
+		l := New("", tt.input)
+
+		var tok token.Token
+		for tok = l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			if tok.Type == tt.expectedType {
+				break
+			}
+		}
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("no %s token for %q", tt.expectedType, tt.input)
+		}
+		if len(l.Errors()) != 1 {
+			t.Fatalf("wrong number of errors for %q. expected=1, got=%d (%v)", tt.input, len(l.Errors()), l.Errors())
+		}
+		if l.Errors()[0].String() != tt.expectedError {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, l.Errors()[0])
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := `let größe = "héllo"; 名前 + größe`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.LET, "let", 1},
+		{token.IDENT, "größe", 5},
+		{token.ASSIGN, "=", 11},
+		{token.STRING, "héllo", 13},
+		{token.SEMICOLON, ";", 20},
+		{token.IDENT, "名前", 22},
+		{token.PLUS, "+", 25},
+		{token.IDENT, "größe", 27},
+		{token.EOF, "", 32},
+	}
+
+	l := New("", input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - column wrong. expected=%d, got=%d", i, tt.expectedColumn, tok.Pos.Column)
+		}
+	}
+}
+
+func TestIntegerLiterals(t *testing.T) {
+	input := `0xff 0XAB_cd 0b1010 0o17 1_000_000 0b102 1_000.5 0x1e5`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "0xff"},
+		{token.INT, "0XAB_cd"},
+		{token.INT, "0b1010"},
+		{token.INT, "0o17"},
+		{token.INT, "1_000_000"},
+		{token.INT, "0b102"},
+		{token.FLOAT, "1_000.5"},
+		{token.INT, "0x1e5"},
+		{token.EOF, ""},
+	}
+
+	l := New("", input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	input := `"Hello ${user["name"]}, you are ${ {"a": 1}["a"] + age }!" "\${x}" "${x}"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INTERP_START, "Hello "},
+		{token.IDENT, "user"},
+		{token.LBRACKET, "["},
+		{token.STRING, "name"},
+		{token.RBRACKET, "]"},
+		{token.INTERP_MIDDLE, ", you are "},
+		{token.LBRACE, "{"},
+		{token.STRING, "a"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "a"},
+		{token.RBRACKET, "]"},
+		{token.PLUS, "+"},
+		{token.IDENT, "age"},
+		{token.INTERP_END, "!"},
+		{token.STRING, "${x}"},
+		{token.INTERP_START, ""},
+		{token.IDENT, "x"},
+		{token.INTERP_END, ""},
+		{token.EOF, ""},
+	}
+
+	l := New("", input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	// a long line with multi-byte characters, read one byte at a time, has to give the same tokens as a string.
+	var input strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&input, "let größe%d = \"wert %d\"; /* comment */ ", i, i)
+	}
+	input.WriteString("\n\"" + strings.Repeat("x", 100000) + "\"")
+
+	expected := New("big.mkl", input.String())
+	l := NewReader("big.mkl", iotest.OneByteReader(strings.NewReader(input.String())))
+
+	for i := 0; ; i++ {
+		want := expected.NextToken()
+		got := l.NextToken()
+
+		if got.Type != want.Type || got.Literal != want.Literal {
+			t.Fatalf("token[%d] wrong. expected=%s %q, got=%s %q", i, want.Type, want.Literal, got.Type, got.Literal)
+		}
+		if got.Pos != want.Pos || got.End != want.End {
+			t.Fatalf("token[%d] position wrong. expected=%s-%s, got=%s-%s", i, want.Pos, want.End, got.Pos, got.End)
+		}
+		if len(got.Leading) != len(want.Leading) {
+			t.Fatalf("token[%d] comments wrong. expected=%d, got=%d", i, len(want.Leading), len(got.Leading))
+		}
+		if got.Type == token.EOF {
+			break
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
+
+func TestNewReaderError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("let x"), iotest.ErrReader(errors.New("disk failure")))
+	l := NewReader("broken.mkl", r)
+
+	tok := l.NextToken()
+	if tok.Type != token.LET {
+		t.Fatalf("tokentype wrong. expected=%q, got=%q", token.LET, tok.Type)
+	}
+	for tok.Type != token.EOF {
+		tok = l.NextToken()
+	}
+
+	if len(l.Errors()) != 1 || !strings.Contains(l.Errors()[0].String(), "could not read input: disk failure") {
+		t.Errorf("expected a read error, got = %v", l.Errors())
+	}
+}
+
+func TestRawStrings(t *testing.T) {
+	input := "`C:\\path\\n \"quoted\" ${x}` `two\nlines` `\n    SELECT *\n      FROM t\n\n    WHERE x\n    ` after"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.STRING, `C:\path\n "quoted" ${x}`, 1, 1},
+		{token.STRING, "two\nlines", 1, 27},
+		{token.STRING, "SELECT *\n  FROM t\n\nWHERE x\n", 2, 8},
+		{token.IDENT, "after", 7, 7},
+		{token.EOF, "", 7, 12},
+	}
+
+	l := New("", input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - line:column wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Pos.Line, tok.Pos.Column)
+		}
+	}
+
+	l = New("", "x `never closed")
+	l.NextToken()
+	if tok := l.NextToken(); tok.Type != token.ILLEGAL {
+		t.Errorf("tokentype wrong. expected=%q, got=%q", token.ILLEGAL, tok.Type)
+	}
+	if len(l.Errors()) != 1 || l.Errors()[0].String() != "1:3: unterminated raw string literal" {
+		t.Errorf("wrong errors, got = %v", l.Errors())
+	}
+}
@@ -1,20 +1,35 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"monkeylang/evaluator"
 	"monkeylang/repl"
 	"os"
 	"os/user"
 )
 
 func main() {
+	strict := flag.Bool("strict", false, "report errors for indexes out of range and missing elements instead of using null")
+	flag.Parse()
+
+	evaluator.Strict = *strict
+
+	// with a file name we run the file instead of starting the REPL.
+	if flag.NArg() > 0 {
+		if err := repl.RunFile(flag.Arg(0), os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	user, err := user.Current()
 
 	if err != nil {
 		panic(err)
 	}
 	fmt.Printf("Hello %s! This is the Monkey Programming Language!\n", user.Username)
-	fmt.Print("Reading from scratch/input.mkl ...\n\n")
 
 	//fmt.Printf("Feel free to type in commands\n")
 	repl.Start(os.Stdin, os.Stdout)
@@ -4,12 +4,13 @@ package object
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
 
-	return &Environment{store: s, outer: nil}
+	return &Environment{store: s, consts: make(map[string]bool), outer: nil}
 }
 
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store  map[string]Object
+	consts map[string]bool // names in store which were bound with const
+	outer  *Environment
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -22,12 +23,46 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// Set binds name in this environment, it returns an *Error instead if name is a constant here.
+// Constants of outer environments can still be shadowed.
 func (e *Environment) Set(name string, val Object) Object {
+	if e.consts[name] {
+		return &Error{Message: "cannot redefine constant " + name}
+	}
+
 	e.store[name] = val
 
 	return val
 }
 
+// SetConst binds name like Set and marks the binding as constant.
+func (e *Environment) SetConst(name string, val Object) Object {
+	if result := e.Set(name, val); result.Type() == ERROR_OBJ {
+		return result
+	}
+	e.consts[name] = true
+
+	return val
+}
+
+// Assign updates the binding of name in the nearest environment which has it,
+// it reports false if the name was never declared and returns an *Error if it is a constant.
+func (e *Environment) Assign(name string, val Object) (Object, bool) {
+	if _, ok := e.store[name]; ok {
+		if e.consts[name] {
+			return &Error{Message: "cannot assign to constant " + name}, true
+		}
+		e.store[name] = val
+		return val, true
+	}
+
+	if e.outer != nil {
+		return e.outer.Assign(name, val)
+	}
+
+	return nil, false
+}
+
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
@@ -3,7 +3,10 @@ package object
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"monkeylang/ast"
+	"monkeylang/token"
+	"strconv"
 	"strings"
 
     "hash/fnv"
@@ -11,14 +14,18 @@ import (
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	ERROR_OBJ        = "ERROR"
 	FUNCTION_OBJ     = "FUNCTION"
 	STRING_OBJ       = "STRING"
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
+	RANGE_OBJ        = "RANGE"
     HASH_OBJ         = "HASH"
 )
 
@@ -44,6 +51,28 @@ func (i *Integer) Type() ObjectType {
 
 // end Integer Data Type
 
+// begin Float Data Type -> satisfies the Object interface
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+
+	// keep floats and integers apart when they are printed, 1.0 should not look like 1.
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+
+	return s
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+// end Float Data Type
+
 // begin Boolean Data Type -> satisfies the Object interface
 type Boolean struct {
 	Value bool
@@ -87,15 +116,32 @@ func (rv *ReturnValue) Inspect() string {
 
 // end RETURNVALUE Data Type
 
+// begin Break and Continue Data Types -> signals which leave the loop body, like ReturnValue
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+
+// end Break and Continue Data Types
+
 // begin Error Data Type -> satisfies the Error interface
 type Error struct {
 	Message string
+	Pos     token.Position // where in the source the error happened, if known
 }
 
 func (e *Error) Type() ObjectType {
 	return ERROR_OBJ
 }
 func (e *Error) Inspect() string {
+	if e.Pos.IsValid() {
+		return "ERROR: " + e.Pos.String() + ": " + e.Message
+	}
 	return "ERROR: " + e.Message
 }
 
@@ -105,6 +151,8 @@ func (e *Error) Inspect() string {
 
 type Function struct {
 	Parameters []*ast.Identifier
+	Defaults   []ast.Expression // evaluated on every call which leaves the parameter out
+	Rest       *ast.Identifier  // collects the extra arguments into an Array, may be nil
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -116,8 +164,15 @@ func (f *Function) Type() ObjectType {
 func (f *Function) Inspect() string {
 	var out bytes.Buffer
 	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
+	for i, p := range f.Parameters {
+		if i < len(f.Defaults) && f.Defaults[i] != nil {
+			params = append(params, p.String()+" = "+f.Defaults[i].String())
+		} else {
+			params = append(params, p.String())
+		}
+	}
+	if f.Rest != nil {
+		params = append(params, "..."+f.Rest.String())
 	}
 	out.WriteString("fn")
 	out.WriteString("(")
@@ -179,12 +234,27 @@ func (a *Array) Inspect() string {
 
 //end Array
 
+// start Range, the integers from Start up to but not including End, counted lazily
+type Range struct {
+	Start int64
+	End   int64
+	Step  int64
+}
+
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+func (r *Range) Inspect() string {
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.End, r.Step)
+}
+
+// end Range
+
 type Hashable interface { 
     HashKey() HashKey
     /* only implemented by 
     *object.String
     *object.Boolean
     *object.Intege
+    *object.Float
     */
 }
 
@@ -209,6 +279,10 @@ func (i * Integer) HashKey() HashKey {
     return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+func (f *Float) HashKey() HashKey {
+    return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
 func (s * String) HashKey() HashKey {
     h := fnv.New64a()
     h.Write([]byte(s.Value))
@@ -21,3 +21,70 @@ func TestStringHashKey(t * testing.T) {
     }
 }
 
+
+func TestEnvironmentAssign(t *testing.T) {
+    outer := NewEnvironment()
+    outer.Set("x", &Integer{Value: 1})
+    inner := NewEnclosedEnvironment(outer)
+
+    if _, ok := inner.Assign("x", &Integer{Value: 2}); !ok {
+        t.Fatalf("Assign did not find x in the outer environment")
+    }
+    if _, ok := inner.store["x"]; ok {
+        t.Errorf("Assign created a new binding in the inner environment")
+    }
+    if val, _ := outer.Get("x"); val.(*Integer).Value != 2 {
+        t.Errorf("outer binding was not updated, got = %s", val.Inspect())
+    }
+
+    if _, ok := inner.Assign("y", &Integer{Value: 3}); ok {
+        t.Errorf("Assign reported success for an undeclared name")
+    }
+    if _, ok := inner.Get("y"); ok {
+        t.Errorf("Assign declared an undeclared name")
+    }
+}
+
+func TestEnvironmentConstants(t *testing.T) {
+    outer := NewEnvironment()
+    outer.SetConst("limit", &Integer{Value: 10})
+
+    if result := outer.Set("limit", &Integer{Value: 20}); result.Type() != ERROR_OBJ {
+        t.Errorf("Set redefined a constant, got = %s", result.Inspect())
+    }
+    if result := outer.SetConst("limit", &Integer{Value: 20}); result.Type() != ERROR_OBJ {
+        t.Errorf("SetConst redefined a constant, got = %s", result.Inspect())
+    }
+
+    inner := NewEnclosedEnvironment(outer)
+    result, ok := inner.Assign("limit", &Integer{Value: 30})
+    if !ok || result.Type() != ERROR_OBJ {
+        t.Errorf("Assign changed a constant, got = %v, %t", result, ok)
+    }
+    if val, _ := outer.Get("limit"); val.(*Integer).Value != 10 {
+        t.Errorf("constant was changed, got = %s", val.Inspect())
+    }
+
+    if result := inner.Set("limit", &Integer{Value: 40}); result.Type() == ERROR_OBJ {
+        t.Errorf("Set could not shadow a constant of an outer environment: %s", result.Inspect())
+    }
+}
+
+func TestFloatInspect(t *testing.T) {
+    tests := []struct {
+        value    float64
+        expected string
+    }{
+        {1, "1.0"},
+        {3.14, "3.14"},
+        {-2, "-2.0"},
+        {1e21, "1e+21"},
+    }
+
+    for _, tt := range tests {
+        f := &Float{Value: tt.value}
+        if f.Inspect() != tt.expected {
+            t.Errorf("wrong Inspect() for %g, expected = %q, got = %q", tt.value, tt.expected, f.Inspect())
+        }
+    }
+}
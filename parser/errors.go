@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"monkeylang/lexer"
+	"monkeylang/token"
+)
+
+// ErrorKind tells what went wrong, so callers can handle errors without looking at the message.
+type ErrorKind int
+
+const (
+	LexicalError            ErrorKind = iota // the lexer could not read the input, e.g. an unterminated string
+	UnexpectedToken                          // a different token was expected next
+	NoPrefixParseFn                          // the token cannot start an expression
+	InvalidInteger                           // an integer literal with invalid digits
+	IntegerOutOfRange                        // an integer literal which does not fit in an int64
+	InvalidFloat                             // a float literal which could not be parsed
+	InvalidAssignmentTarget                  // the left side of an assignment is not a name or an index expression
+	InvalidParameter                         // a parameter without a default after one with a default, or an arrow parameter which is not a plain name
+	InvalidPipeline                          // the right side of |> is not a call
+)
+
+var errorKindNames = map[ErrorKind]string{
+	LexicalError:            "LexicalError",
+	UnexpectedToken:         "UnexpectedToken",
+	NoPrefixParseFn:         "NoPrefixParseFn",
+	InvalidInteger:          "InvalidInteger",
+	IntegerOutOfRange:       "IntegerOutOfRange",
+	InvalidFloat:            "InvalidFloat",
+	InvalidAssignmentTarget: "InvalidAssignmentTarget",
+	InvalidParameter:        "InvalidParameter",
+	InvalidPipeline:         "InvalidPipeline",
+}
+
+func (k ErrorKind) String() string {
+	if name, ok := errorKindNames[k]; ok {
+		return name
+	}
+
+	return "UnknownError"
+}
+
+// ParseError is a syntax error with everything an editor needs to show it.
+type ParseError struct {
+	Kind     ErrorKind
+	Expected []token.TokenType // the token types which would have been valid, only for UnexpectedToken
+	Found    token.Token       // the token at which the error was found, empty for lexical errors
+	Pos      token.Position    // start of the source span with the error
+	End      token.Position    // position immediately after the span
+	Message  string
+}
+
+// formats the error as position: message, the way the REPL prints it.
+func (e *ParseError) String() string {
+	return e.Pos.String() + ": " + e.Message
+}
+
+func (e *ParseError) Error() string {
+	return e.String()
+}
+
+// creates an error which spans the given token.
+func newTokenError(kind ErrorKind, found token.Token, message string) *ParseError {
+	return &ParseError{Kind: kind, Found: found, Pos: found.Pos, End: found.End, Message: message}
+}
+
+// wraps an error of the lexer.
+func newLexicalError(err *lexer.Error) *ParseError {
+	return &ParseError{Kind: LexicalError, Pos: err.Pos, End: err.End, Message: err.Message}
+}
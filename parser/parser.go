@@ -1,43 +1,87 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"monkeylang/ast"
 	"monkeylang/lexer"
 	"monkeylang/token"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 const (
 	// increases one by one
 	_ int = iota
 	LOWEST
+	ASSIGN
+	PIPE
+	LOGICALOR
+	LOGICALAND
 	EQUALS
 	LESSGREATER
+	BITOR
+	BITXOR
+	BITAND
+	SHIFT
 	SUM
 	PRODUCT
 	PREFIX
+	POWER // binds tighter than a prefix operator on its left, -2 ** 2 is -(2 ** 2)
 	CALL
 	INDEX
 )
 
 var precendences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.ASTERISK_ASSIGN: ASSIGN,
+	token.SLASH_ASSIGN:    ASSIGN,
+	token.PIPE:            PIPE,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LT_EQ:           LESSGREATER,
+	token.GT_EQ:           LESSGREATER,
+	token.AND:             LOGICALAND,
+	token.OR:              LOGICALOR,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.PERCENT:         PRODUCT,
+	token.POWER:           POWER,
+	token.BIT_OR:          BITOR,
+	token.BIT_XOR:         BITXOR,
+	token.BIT_AND:         BITAND,
+	token.SHL:             SHIFT,
+	token.SHR:             SHIFT,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+	token.DOT:             INDEX,
 }
 
 // a pratt parser will create an associations between token types and functions that will parse the token
 type Parser struct {
 	l      *lexer.Lexer // takes lexer as an input
-	errors []string
+	errors []*ParseError
+
+	// set by the first error in a statement, while it is set we do not report more errors
+	// because they are usually caused by the first one. it is cleared once we have skipped to the next statement.
+	panicking bool
+	// set when recovering stopped on the first token of the next statement instead of just before it.
+	resume bool
+	// the number of '{' which are open at the current token, used to skip over whole blocks while recovering.
+	braces int
+	// the number of those '{' which opened a hash literal or a hash pattern, they are not skipped as blocks.
+	hashes int
+
+	// set while parsing the guard of a match arm, where => ends the guard instead of starting an arrow function.
+	noArrow bool
 
 	// reading through the file.
 	curToken  token.Token
@@ -65,13 +109,18 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 	// if the prefix function is nil, then we have an error
 	if prefix == nil {
-		p.noPrefixParseFnError(p.curToken.Type)
+		p.noPrefixParseFnError(p.curToken)
 		return nil
 	}
 
 	// we call the prefix function
 	leftExp := prefix()
 
+	// the rest of the expression cannot be parsed without its left side.
+	if p.panicking {
+		return nil
+	}
+
 	// we loop until we encounter a semicolon or the precedence of the next token is lower than the precedence of the current token
 	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
@@ -83,6 +132,9 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		p.nextToken()
 
 		leftExp = infix(leftExp)
+		if p.panicking {
+			return nil
+		}
 	}
 
 	return leftExp
@@ -105,7 +157,7 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 }
 
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{}}
+	p := &Parser{l: l, errors: []*ParseError{}}
 
 	p.nextToken()
 	p.nextToken()
@@ -118,12 +170,18 @@ func New(l *lexer.Lexer) *Parser {
 	// 5
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
 
+	// 3.14
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+
 	// !something
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 
 	// -something
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 
+	// ~something
+	p.registerPrefix(token.BIT_NOT, p.parsePrefixExpression)
+
 	// TRUE
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 
@@ -139,9 +197,15 @@ func New(l *lexer.Lexer) *Parser {
 	// functions
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 
+	// match expressions
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+
 	// stringliterals
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 
+	// "strings with ${expressions}"
+	p.registerPrefix(token.INTERP_START, p.parseInterpolatedString)
+
 	// Array Literal
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 
@@ -174,12 +238,50 @@ func New(l *lexer.Lexer) *Parser {
 	// something > something
 	p.registerInfix(token.GT, p.parseInfixExpression)
 
+	// something <= something
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+
+	// something >= something
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+
+	// something && something
+	p.registerInfix(token.AND, p.parseInfixExpression)
+
+	// something || something
+	p.registerInfix(token.OR, p.parseInfixExpression)
+
+	// something % something
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+
+	// something ** something
+	p.registerInfix(token.POWER, p.parseInfixExpression)
+
+	// bitwise operators
+	p.registerInfix(token.BIT_AND, p.parseInfixExpression)
+	p.registerInfix(token.BIT_OR, p.parseInfixExpression)
+	p.registerInfix(token.BIT_XOR, p.parseInfixExpression)
+	p.registerInfix(token.SHL, p.parseInfixExpression)
+	p.registerInfix(token.SHR, p.parseInfixExpression)
+
 	// call expressions
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 
+	// something |> call(), which is call(something)
+	p.registerInfix(token.PIPE, p.parsePipeExpression)
+
 	// Index expression
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
 
+	// member access and method calls
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+
+	// something = something, something += something, ...
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
+
 	return p
 }
 
@@ -189,6 +291,17 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 		Operator: p.curToken.Literal,
 	}
 
+	// the smallest integer can only be written negated, 9223372036854775808 alone is out of range.
+	if expression.Operator == "-" && p.peekTokenIs(token.INT) {
+		if value, err := strconv.ParseUint(p.peekToken.Literal, 0, 64); err == nil && value == 1<<63 {
+			p.nextToken()
+			tok := p.curToken
+			tok.Literal = "-" + tok.Literal
+			tok.Pos = expression.Token.Pos
+			return &ast.IntegerLiteral{Token: tok, Value: math.MinInt64}
+		}
+	}
+
 	p.nextToken()
 
 	expression.Right = p.parseExpression(PREFIX)
@@ -204,6 +317,12 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedence := p.curPrecedence()
+
+	// ** is right associative, 2 ** 3 ** 2 is 2 ** (3 ** 2)
+	if p.curTokenIs(token.POWER) {
+		precedence--
+	}
+
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
@@ -211,15 +330,46 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 }
 
 func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	// x => x * 2
+	if p.peekTokenIs(token.ARROW) && !p.noArrow {
+		lit := &ast.FunctionLiteral{
+			Token:      p.curToken,
+			Parameters: []*ast.Identifier{ident},
+			Defaults:   []ast.Expression{nil},
+		}
+		return p.parseArrowFunction(lit)
+	}
+
+	return ident
 }
-func (p *Parser) Errors() []string {
-	return p.errors
+// returns the errors of the lexer and of the parser in the order they appear in the source.
+func (p *Parser) Errors() []*ParseError {
+	all := []*ParseError{}
+	for _, err := range p.l.Errors() {
+		all = append(all, newLexicalError(err))
+	}
+	all = append(all, p.errors...)
+
+	// stable, so an error of the lexer stays in front of a parser error at the same position.
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Pos.Offset < all[j].Pos.Offset
+	})
+
+	return all
 }
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	switch {
+	case p.curTokenIs(token.LBRACE):
+		p.braces++
+	case p.curTokenIs(token.RBRACE) && p.braces > 0:
+		p.braces--
+	}
 }
 
 // The function of the Parser.
@@ -233,21 +383,147 @@ func (p *Parser) ParseProgram() *ast.Program {
 	for p.curToken.Type != token.EOF {
 
 		// we are parsing the statements and appending them to the program.
-		stmt := p.parseStatement()
-		// NOTE : we removed an if check here which checked if stmt is nil
-		program.Statements = append(program.Statements, stmt)
-		p.nextToken()
+		// statements with errors are left out, so the rest of the program can still be used.
+		if stmt := p.parseStatementOrRecover(); stmt != nil {
+			program.Statements = append(program.Statements, stmt)
+		}
+		p.nextStatement()
 	}
 
 	return program
 }
 
+// parses a statement, if that fails it skips ahead to the next statement and returns nil.
+func (p *Parser) parseStatementOrRecover() ast.Statement {
+	start := p.curToken
+	// the depth of the block the statement is in, not counting the statement's own '{'.
+	depth := p.braces
+	if start.Type == token.LBRACE {
+		depth--
+	}
+	hashes := p.hashes
+	// a statement inside a block of a statement which already failed leaves the recovery to that statement.
+	outer := p.panicking
+	stmt := p.parseStatement()
+
+	if p.panicking {
+		if !outer {
+			p.synchronize(start, depth, hashes)
+			p.panicking = false
+		}
+		return nil
+	}
+
+	return stmt
+}
+
+// skips tokens until a new statement can start: after a ';', before the '}' which closes the enclosing block
+// or at a statement keyword. blocks opened by the statement itself are skipped as a whole, so the
+// body of `if (a > b { return a; }` is not parsed as statements of its own.
+// the current token is normally left on the last skipped token, so the caller moves on with nextStatement.
+// a hash literal which was not closed, e.g. `let h = {"a": 1; let y = 2;`, does not hide the statements after it.
+func (p *Parser) synchronize(start token.Token, depth int, hashes int) {
+	// the error was found at the '}' which closes the enclosing block, e.g. `fn() { 5 + }`
+	if p.braces < depth {
+		p.resume = true
+		return
+	}
+
+	// the hashes of the statement which are still open, they are closed by a '}' which does not
+	// belong to a block opened while skipping.
+	open := p.hashes - hashes
+	opened := 0
+	defer func() {
+		p.braces -= open
+		p.hashes = hashes
+	}()
+
+	for !p.curTokenIs(token.EOF) {
+		nested := p.braces-open > depth
+
+		if !nested {
+			if p.curTokenIs(token.SEMICOLON) {
+				return
+			}
+			// the error was found at a keyword which starts the next statement, e.g. `return 5 + let x = 1;`
+			if isStatementKeyword(p.curToken.Type) && p.curToken.Pos != start.Pos {
+				p.resume = true
+				return
+			}
+			if p.peekTokenIs(token.EOF) || isStatementKeyword(p.peekToken.Type) {
+				return
+			}
+			// a '}' which closes the enclosing block ends the statement, at the top level there is
+			// no block to close and the stray '}' is skipped.
+			if p.peekTokenIs(token.RBRACE) && depth > 0 && open == 0 {
+				return
+			}
+		}
+
+		p.nextToken()
+		switch {
+		case p.curTokenIs(token.LBRACE):
+			opened++
+		case p.curTokenIs(token.RBRACE) && opened > 0:
+			opened--
+		case p.curTokenIs(token.RBRACE) && open > 0:
+			open--
+		}
+	}
+}
+
+// moves to the first token of the next statement.
+func (p *Parser) nextStatement() {
+	if p.resume {
+		p.resume = false
+		return
+	}
+
+	p.nextToken()
+}
+
+// the tokens which can only start a statement.
+func isStatementKeyword(t token.TokenType) bool {
+	switch t {
+	case token.LET, token.CONST, token.RETURN, token.WHILE, token.FOR, token.BREAK, token.CONTINUE:
+		return true
+	default:
+		return false
+	}
+}
+
+// records an error, unless we are still skipping over the statement which had an error before.
+func (p *Parser) addError(err *ParseError) {
+	if p.panicking {
+		return
+	}
+
+	p.errors = append(p.errors, err)
+	p.panicking = true
+}
+
 func (p *Parser) parseStatement() ast.Statement { // this is a helper method for the ParseProgram method
 	switch p.curToken.Type {
-	case token.LET:
+	case token.LET, token.CONST:
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK:
+		stmt := &ast.BreakStatement{Token: p.curToken}
+		if p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
+	case token.CONTINUE:
+		stmt := &ast.ContinueStatement{Token: p.curToken}
+		if p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -256,7 +532,8 @@ func (p *Parser) parseStatement() ast.Statement { // this is a helper method for
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 	stmt.Expression = p.parseExpression(LOWEST)
-	if p.peekTokenIs(token.SEMICOLON) {
+	// after an error the ';' is left to synchronize, the error may have been at the '}' before it.
+	if !p.panicking && p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stmt
@@ -265,9 +542,30 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 
+	if errors.Is(err, strconv.ErrRange) {
+		msg := fmt.Sprintf("integer literal %s is out of range, integers must be between %d and %d",
+			p.curToken.Literal, int64(math.MinInt64), int64(math.MaxInt64))
+		p.addError(newTokenError(IntegerOutOfRange, p.curToken, msg))
+		return nil
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(newTokenError(InvalidInteger, p.curToken, msg))
+		return nil
+	}
+	lit.Value = value
+
+	return lit
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.addError(newTokenError(InvalidFloat, p.curToken, msg))
 		return nil
 	}
 	lit.Value = value
@@ -280,44 +578,429 @@ func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
 
-// parseGroupedExpression is for grouped Expression
+// parseGroupedExpression is for grouped Expression, and for the parameters of arrow functions like (a, b) => a + b.
+// Both start with (, so the contents are parsed as a list of expressions first and turned into
+// parameters once the => after the ) shows that they were parameters.
 func (p *Parser) parseGroupedExpression() ast.Expression {
-	p.nextToken()
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+
+	// () can only start an arrow function
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return p.parseArrowFunction(lit)
+	}
+
+	// a => inside the parentheses cannot end a match guard
+	noArrow := p.noArrow
+	p.noArrow = false
+
+	list := []ast.Expression{}
+	// the elements which start with a '(', they cannot be parameters, e.g. ((a)) => 1
+	grouped := []bool{}
+	for {
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			lit.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			break
+		}
 
-	exp := p.parseExpression(LOWEST)
+		p.nextToken()
+		grouped = append(grouped, p.curTokenIs(token.LPAREN))
+		exp := p.parseExpression(LOWEST)
+		if exp == nil {
+			return nil
+		}
+		list = append(list, exp)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	p.noArrow = noArrow
 
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
 
-	return exp
+	if p.peekTokenIs(token.ARROW) && !p.noArrow {
+		if !p.arrowParameters(lit, list, grouped) {
+			return nil
+		}
+		return p.parseArrowFunction(lit)
+	}
+
+	// a list or a ...rest in parentheses has to be the parameters of an arrow function
+	if len(list) != 1 || lit.Rest != nil {
+		p.peekError(token.ARROW)
+		return nil
+	}
+
+	return list[0]
+}
+
+// turns the expressions in (a, b = 1) into the parameters of the arrow function.
+func (p *Parser) arrowParameters(lit *ast.FunctionLiteral, list []ast.Expression, grouped []bool) bool {
+	lit.Parameters = []*ast.Identifier{}
+	lit.Defaults = []ast.Expression{}
+
+	for i, exp := range list {
+		var ident *ast.Identifier
+		var value ast.Expression
+
+		switch exp := exp.(type) {
+		case *ast.Identifier:
+			ident = exp
+		case *ast.AssignExpression:
+			if target, ok := exp.Target.(*ast.Identifier); ok && exp.Operator == "=" {
+				ident, value = target, exp.Value
+			}
+		}
+
+		if ident != nil && grouped[i] {
+			msg := fmt.Sprintf("parameter %s in arrow function must not be in parentheses", ident.Value)
+			err := newTokenError(InvalidParameter, p.curToken, msg)
+			err.Pos, err.End = exp.Pos(), exp.End()
+			p.addError(err)
+			return false
+		}
+		if ident == nil {
+			msg := fmt.Sprintf("invalid parameter %s in arrow function", exp.String())
+			err := newTokenError(InvalidParameter, p.curToken, msg)
+			err.Pos, err.End = exp.Pos(), exp.End()
+			p.addError(err)
+			return false
+		}
+		if value == nil && len(lit.Defaults) > 0 && lit.Defaults[len(lit.Defaults)-1] != nil {
+			msg := fmt.Sprintf("parameter %s without a default value follows a parameter with a default value", ident.Value)
+			p.addError(newTokenError(InvalidParameter, ident.Token, msg))
+			return false
+		}
+
+		lit.Parameters = append(lit.Parameters, ident)
+		lit.Defaults = append(lit.Defaults, value)
+	}
+
+	return true
+}
+
+// parses the => and the body of an arrow function, the body is a single expression
+// which becomes the only statement of the function body.
+func (p *Parser) parseArrowFunction(lit *ast.FunctionLiteral) ast.Expression {
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+	lit.Arrow = p.curToken
+
+	p.nextToken()
+	start := p.curToken
+
+	body := p.parseExpression(LOWEST)
+	if body == nil {
+		return nil
+	}
+
+	lit.Body = &ast.BlockStatement{
+		Token:      start,
+		Statements: []ast.Statement{&ast.ExpressionStatement{Token: start, Expression: body}},
+		Rbrace:     token.Token{Pos: body.End(), End: body.End()}, // there is no }, the body ends with the expression
+	}
+
+	return lit
 }
 
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
 
+	// xs[:high] has no index before the colon
+	if p.peekTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp.Token, left, nil)
+	}
+
 	p.nextToken()
 
 	exp.Index = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp.Token, left, exp.Index)
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
+	exp.Rbracket = p.curToken
 
 	return exp
 }
 
-func (p *Parser) parseLetStatement() *ast.LetStatement { // this is a helper method for the parseStatement method
+// rewrites xs |> push(1) into push(xs, 1), so the left value becomes the first argument of the call.
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	precedence := p.curPrecedence()
+	p.nextToken()
 
-	stmt := &ast.LetStatement{Token: p.curToken} // create a new let statement
+	right := p.parseExpression(precedence)
+	if right == nil {
+		return nil
+	}
 
-	// ? The expectPeek method also moves the pointer ahead - keep in mind
-	if !p.expectPeek(token.IDENT) { // if the next token is not an identifier, then something is wrong in the program
+	call, ok := right.(*ast.CallExpression)
+	if !ok {
+		msg := fmt.Sprintf("right side of |> must be a call, got %s", right.String())
+		err := newTokenError(InvalidPipeline, p.curToken, msg)
+		err.Pos, err.End = right.Pos(), right.End()
+		p.addError(err)
 		return nil
 	}
 
-	// the name of a statement is an IDENTIFIER.
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal} // set the name of the let statement
+	return &ast.CallExpression{
+		Token:     call.Token,
+		Function:  call.Function,
+		Arguments: append([]ast.Expression{left}, call.Arguments...),
+		Rparen:    call.Rparen,
+	}
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: object}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
+// parses the rest of xs[low:high] from the colon, after the low index.
+func (p *Parser) parseSliceExpression(bracket token.Token, left, low ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{Token: bracket, Left: left, Low: low}
+
+	p.nextToken()
+
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.High = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	exp.Rbracket = p.curToken
+
+	return exp
+}
+
+// parses the pattern starting at the current token, literals are only allowed in the patterns of match.
+func (p *Parser) parsePattern(allowLiterals bool) ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	case token.LBRACKET:
+		return p.parseArrayPattern(allowLiterals)
+	case token.LBRACE:
+		return p.parseHashPattern(allowLiterals)
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE, token.MINUS:
+		if allowLiterals {
+			value := p.parseExpression(PREFIX)
+			if value == nil {
+				return nil
+			}
+			return &ast.LiteralPattern{Value: value}
+		}
+	}
+
+	expected := []token.TokenType{token.IDENT, token.LBRACKET, token.LBRACE}
+	msg := fmt.Sprintf("unexpected token in pattern: expected = {IDENT, [, {} | got = {%s}", p.curToken.Type)
+	err := newTokenError(UnexpectedToken, p.curToken, msg)
+	err.Expected = expected
+	p.addError(err)
+	return nil
+}
+
+// parses [a, [b, c], ...rest]
+func (p *Parser) parseArrayPattern(allowLiterals bool) ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACKET) {
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			pattern.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			break
+		}
+
+		p.nextToken()
+		element := p.parsePattern(allowLiterals)
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	pattern.Rbracket = p.curToken
+
+	return pattern
+}
+
+// parses {name, age: years, "first-name": first}
+func (p *Parser) parseHashPattern(allowLiterals bool) ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken}
+	p.hashes++
+
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.peekTokenIs(token.IDENT) && !p.peekTokenIs(token.STRING) {
+			p.peekError(token.IDENT, token.STRING)
+			return nil
+		}
+		p.nextToken()
+		pair := &ast.HashPatternPair{Key: &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}}
+
+		if p.curTokenIs(token.IDENT) && !p.peekTokenIs(token.COLON) {
+			pair.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		} else {
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+			p.nextToken()
+			pair.Value = p.parsePattern(allowLiterals)
+			if pair.Value == nil {
+				return nil
+			}
+		}
+		pattern.Pairs = append(pattern.Pairs, pair)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	pattern.Rbrace = p.curToken
+	p.hashes--
+
+	return pattern
+}
+
+// parsing of match (value) { pattern => result, pattern if guard => result, _ => result }
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		arm := &ast.MatchArm{Pattern: p.parsePattern(true)}
+		if arm.Pattern == nil {
+			return nil
+		}
+
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			p.nextToken()
+			p.noArrow = true
+			arm.Guard = p.parseExpression(LOWEST)
+			p.noArrow = false
+			if arm.Guard == nil {
+				return nil
+			}
+		}
+
+		if !p.expectPeek(token.ARROW) {
+			return nil
+		}
+		p.nextToken()
+		arm.Body = p.parseExpression(LOWEST)
+		if arm.Body == nil {
+			return nil
+		}
+		expression.Arms = append(expression.Arms, arm)
+
+		// the comma after the last arm is optional
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	expression.Rbrace = p.curToken
+
+	return expression
+}
+
+// parsing of assignments, they are right associative so a = b = 1 assigns both.
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression, *ast.MemberExpression:
+	default:
+		msg := fmt.Sprintf("cannot assign to %s", target.String())
+		err := newTokenError(InvalidAssignmentTarget, p.curToken, msg)
+		err.Pos, err.End = target.Pos(), target.End()
+		p.addError(err)
+		return nil
+	}
+
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Target:   target,
+		Operator: p.curToken.Literal,
+	}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(ASSIGN - 1)
+
+	return expression
+}
+
+func (p *Parser) parseLetStatement() *ast.LetStatement { // this is a helper method for the parseStatement method
+
+	stmt := &ast.LetStatement{Token: p.curToken} // create a new let statement
+
+	// let [a, b] = ... and let {a, b} = ... destructure the value instead of binding a single name
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		stmt.Pattern = p.parsePattern(false)
+		if stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		// ? The expectPeek method also moves the pointer ahead - keep in mind
+		if !p.expectPeek(token.IDENT) { // if the next token is not an identifier, then something is wrong in the program
+			return nil
+		}
+
+		// the name of a statement is an IDENTIFIER.
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal} // set the name of the let statement
+	}
 
 	// the next token should be an ASSIGN token, it moves the pointer ahead and checks too, the idea of the expectPeek method is very good.
 	if !p.expectPeek(token.ASSIGN) {
@@ -329,7 +1012,7 @@ func (p *Parser) parseLetStatement() *ast.LetStatement { // this is a helper met
 	stmt.Value = p.parseExpression(LOWEST)
 	// TODO : We're skipping the expressions until we encounter a semicolon
 
-	if p.peekTokenIs(token.SEMICOLON) {
+	if !p.panicking && p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -343,7 +1026,7 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMICOLON) {
+	if !p.panicking && p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -354,16 +1037,52 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	str.Parts = p.appendStringPart(nil)
+
+	for {
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+
+		switch {
+		case p.peekTokenIs(token.INTERP_MIDDLE):
+			p.nextToken()
+			str.Parts = p.appendStringPart(str.Parts)
+		case p.peekTokenIs(token.INTERP_END):
+			p.nextToken()
+			str.Parts = p.appendStringPart(str.Parts)
+			str.EndToken = p.curToken
+			return str
+		default:
+			p.peekError(token.INTERP_MIDDLE, token.INTERP_END)
+			return nil
+		}
+	}
+}
+
+// adds the text of the current INTERP_* token to the parts, empty texts are left out.
+func (p *Parser) appendStringPart(parts []ast.Expression) []ast.Expression {
+	if p.curToken.Literal == "" {
+		return parts
+	}
+
+	return append(parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	array.Rbracket = p.curToken
 
 	return array
 }
 
 func (p * Parser) parseHashLiteral() ast.Expression {
     hash := &ast.HashLiteral{ Token: p.curToken }
+    // counted until the closing }, a hash which is left open by an error is handled by synchronize
+    p.hashes++
     hash.Pairs = make(map[ast.Expression]ast.Expression) 
     
     for !p.peekTokenIs(token.RBRACE) {
@@ -386,6 +1105,8 @@ func (p * Parser) parseHashLiteral() ast.Expression {
     if !p.expectPeek(token.RBRACE) {
         return nil
     }
+    hash.Rbrace = p.curToken
+    p.hashes--
 
     return hash
 }
@@ -393,6 +1114,11 @@ func (p * Parser) parseHashLiteral() ast.Expression {
 func (p *Parser) parseExpressionList(delimiter token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
+	// arrow functions are allowed in arguments and array elements, even inside a match guard
+	noArrow := p.noArrow
+	p.noArrow = false
+	defer func() { p.noArrow = noArrow }()
+
 	if p.peekTokenIs(delimiter) {
 		p.nextToken()
 		return list
@@ -435,15 +1161,34 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	}
 }
 
-func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token error: expected = {%s} | got = {%s}", t, p.peekToken.Type)
+// reports that the next token is not one of the expected ones.
+func (p *Parser) peekError(expected ...token.TokenType) {
+	// the lexer has already reported the illegal token, we only have to skip the statement.
+	if p.peekToken.Type == token.ILLEGAL {
+		p.panicking = true
+		return
+	}
+
+	names := []string{}
+	for _, t := range expected {
+		names = append(names, string(t))
+	}
+	msg := fmt.Sprintf("expected next token error: expected = {%s} | got = {%s}", strings.Join(names, ", "), p.peekToken.Type)
 
-	p.errors = append(p.errors, msg)
+	err := newTokenError(UnexpectedToken, p.peekToken, msg)
+	err.Expected = expected
+	p.addError(err)
 }
 
-func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+func (p *Parser) noPrefixParseFnError(t token.Token) {
+	// the lexer has already reported why the token is illegal, we only have to skip the statement.
+	if t.Type == token.ILLEGAL {
+		p.panicking = true
+		return
+	}
+
+	msg := fmt.Sprintf("no prefix parse function for %s found", t.Type)
+	p.addError(newTokenError(NoPrefixParseFn, t, msg))
 }
 
 // parsing of if-else statements
@@ -454,6 +1199,9 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	}
 	p.nextToken()
 	expression.Condition = p.parseExpression(LOWEST)
+	if p.panicking {
+		return nil
+	}
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
@@ -466,9 +1214,29 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
 
-		if !p.expectPeek(token.LBRACE) {
+		// else if (...) { ... } becomes an alternative block holding just the nested if expression
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			ifToken := p.curToken
+
+			nested := p.parseIfExpression()
+			if nested == nil {
+				return nil
+			}
+
+			expression.Alternative = &ast.BlockStatement{
+				Token:      ifToken,
+				Statements: []ast.Statement{&ast.ExpressionStatement{Token: ifToken, Expression: nested}},
+				Rbrace:     p.curToken,
+			}
+			return expression
+		}
+
+		if !p.peekTokenIs(token.LBRACE) {
+			p.peekError(token.LBRACE, token.IF)
 			return nil
 		}
+		p.nextToken()
 
 		expression.Alternative = p.parseBlockStatement()
 	}
@@ -476,17 +1244,86 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+// parsing of while loops
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+	if p.panicking {
+		return nil
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parsing of for loops: for (x in xs) { ... } or for (k, v in hash) { ... }
+func (p *Parser) parseForStatement() *ast.ForStatement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Variables = []*ast.Identifier{{Token: p.curToken, Value: p.curToken.Literal}}
+
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Variables = append(stmt.Variables, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+	if p.panicking {
+		return nil
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
 	p.nextToken()
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
+		stmt := p.parseStatementOrRecover()
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
-		p.nextToken()
+		p.nextStatement()
 	}
+	block.Rbrace = p.curToken
 	return block
 }
 
@@ -499,7 +1336,9 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	if !p.parseFunctionParameters(lit) {
+		return nil
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
@@ -510,34 +1349,56 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+// parses the parameters of the function up to the closing ), they look like (a, b = 10, ...rest).
+func (p *Parser) parseFunctionParameters(lit *ast.FunctionLiteral) bool {
+	lit.Parameters = []*ast.Identifier{}
+	lit.Defaults = []ast.Expression{}
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
+		return true
 	}
 
-	p.nextToken()
-
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-
-	identifiers = append(identifiers, ident)
-
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
+	for {
+		// the rest parameter has to be the last one
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return false
+			}
+			lit.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			break
+		}
 
+		if !p.expectPeek(token.IDENT) {
+			return false
+		}
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-		identifiers = append(identifiers, ident)
-	}
+		var value ast.Expression
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			value = p.parseExpression(LOWEST)
+			if value == nil {
+				return false
+			}
+		} else if len(lit.Defaults) > 0 && lit.Defaults[len(lit.Defaults)-1] != nil {
+			msg := fmt.Sprintf("parameter %s without a default value follows a parameter with a default value", ident.Value)
+			p.addError(newTokenError(InvalidParameter, ident.Token, msg))
+			return false
+		}
 
-	if !p.expectPeek(token.RPAREN) {
-		return nil
+		lit.Parameters = append(lit.Parameters, ident)
+		lit.Defaults = append(lit.Defaults, value)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
-	return identifiers
+	return p.expectPeek(token.RPAREN)
 }
 
 // call expressions
@@ -546,6 +1407,7 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
 
 	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Rparen = p.curToken
 	return exp
 }
 
@@ -3,6 +3,7 @@ package parser
 import (
 	"monkeylang/ast"
 	"monkeylang/lexer"
+	"monkeylang/token"
 
 	"fmt"
 	"testing"
@@ -10,7 +11,7 @@ import (
 
 func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 	input := `{"one": 0 + 1, "two": 10 - 8, "three": 15 / 5}`
-	l := lexer.New(input)
+	l := lexer.New("", input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
@@ -51,7 +52,7 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 func TestHashingLiteral(t *testing.T) {
 	input := `{ "one": 1, "two": 2, "three": 3}`
 
-	l := lexer.New(input)
+	l := lexer.New("", input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
@@ -84,7 +85,7 @@ func TestHashingLiteral(t *testing.T) {
 
 func TestParsingEmptyHashLiteral(t *testing.T) {
 	input := "{}"
-	l := lexer.New(input)
+	l := lexer.New("", input)
 	p := New(l)
 
 	program := p.ParseProgram()
@@ -101,7 +102,7 @@ func TestParsingEmptyHashLiteral(t *testing.T) {
 
 func TestIndexExpression(t *testing.T) {
 	input := `myArray[1 + 2]`
-	l := lexer.New(input)
+	l := lexer.New("", input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
@@ -122,9 +123,148 @@ func TestIndexExpression(t *testing.T) {
 	}
 }
 
+func TestMemberExpression(t *testing.T) {
+	input := "person.name"
+	p := New(lexer.New("", input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	member, ok := stmt.Expression.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("exp is not ast.MemberExpression. got=%T", stmt.Expression)
+	}
+	if !testIdentifier(t, member.Object, "person") {
+		return
+	}
+	if member.Property.Value != "name" {
+		t.Errorf("member.Property wrong. want=%q, got=%q", "name", member.Property.Value)
+	}
+	if member.Pos().Offset != 0 || member.End().Offset != len(input) {
+		t.Errorf("wrong span. got=%d-%d", member.Pos().Offset, member.End().Offset)
+	}
+}
+
+func TestMethodCallExpression(t *testing.T) {
+	p := New(lexer.New("", "xs.push(4)"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("exp is not ast.CallExpression. got=%T", stmt.Expression)
+	}
+	member, ok := call.Function.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("call.Function is not ast.MemberExpression. got=%T", call.Function)
+	}
+	if member.Property.Value != "push" {
+		t.Errorf("member.Property wrong. want=%q, got=%q", "push", member.Property.Value)
+	}
+	if len(call.Arguments) != 1 {
+		t.Fatalf("wrong number of arguments. got=%d", len(call.Arguments))
+	}
+	testLiteralExpression(t, call.Arguments[0], 4)
+}
+
+func TestPipeExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"xs |> len", "right side of |> must be a call, got len"},
+		{"xs |> 5", "right side of |> must be a call, got 5"},
+		{"xs |> f() + 1", "right side of |> must be a call, got (f() + 1)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("wrong number of errors for %q. want=1, got=%d", tt.input, len(errors))
+			continue
+		}
+		if errors[0].Kind != InvalidPipeline {
+			t.Errorf("wrong error kind for %q. got=%s", tt.input, errors[0].Kind)
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
+		if errors[0].Pos.Column != 7 {
+			t.Errorf("error for %q does not start at the right side. got column=%d", tt.input, errors[0].Pos.Column)
+		}
+	}
+}
+
+func TestMemberExpressionErrors(t *testing.T) {
+	p := New(lexer.New("", "person.1"))
+	p.ParseProgram()
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("no error for a member which is not an identifier")
+	}
+	expected := "expected next token error: expected = {IDENT} | got = {INT}"
+	if errors[0].Message != expected {
+		t.Errorf("wrong error. want=%q, got=%q", expected, errors[0].Message)
+	}
+}
+
+func TestSliceExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		low      string // "" when the bound is left out
+		high     string
+		expected string
+	}{
+		{"xs[1:3]", "1", "3", "(xs[1:3])"},
+		{"xs[:-1]", "", "(-1)", "(xs[:(-1)])"},
+		{"s[2:]", "2", "", "(s[2:])"},
+		{"xs[:]", "", "", "(xs[:])"},
+		{"xs[i + 1:len(xs)]", "(i + 1)", "len(xs)", "(xs[(i + 1):len(xs)])"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		slice, ok := stmt.Expression.(*ast.SliceExpression)
+		if !ok {
+			t.Fatalf("exp is not ast.SliceExpression. got=%T", stmt.Expression)
+		}
+
+		bounds := []struct {
+			name     string
+			node     ast.Expression
+			expected string
+		}{{"low", slice.Low, tt.low}, {"high", slice.High, tt.high}}
+		for _, b := range bounds {
+			got := ""
+			if b.node != nil {
+				got = b.node.String()
+			}
+			if got != b.expected {
+				t.Errorf("%s bound of %q wrong. want=%q, got=%q", b.name, tt.input, b.expected, got)
+			}
+		}
+		if slice.String() != tt.expected {
+			t.Errorf("slice.String() wrong. want=%q, got=%q", tt.expected, slice.String())
+		}
+		if slice.Pos().Offset != 0 || slice.End().Offset != len(tt.input) {
+			t.Errorf("wrong span for %q. got=%d-%d", tt.input, slice.Pos().Offset, slice.End().Offset)
+		}
+		if slice.Token.Type != token.LBRACKET {
+			t.Errorf("slice.Token is not [. got=%q", slice.Token.Type)
+		}
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
-	l := lexer.New(input)
+	l := lexer.New("", input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
@@ -148,7 +288,7 @@ func TestParsingArrayLiterals(t *testing.T) {
 func TestStringLiteral(t *testing.T) {
 	input := `"hello world"`
 
-	l := lexer.New(input)
+	l := lexer.New("", input)
 	p := New(l)
 
 	program := p.ParseProgram()
@@ -167,9 +307,42 @@ func TestStringLiteral(t *testing.T) {
 	}
 }
 
+func TestInterpolatedString(t *testing.T) {
+	input := `"Hello ${name}, you are ${age + 1}"`
+
+	l := lexer.New("", input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString, got = %T", stmt.Expression)
+	}
+
+	if len(str.Parts) != 4 {
+		t.Fatalf("wrong number of parts, expected = 4, got = %d", len(str.Parts))
+	}
+
+	if lit, ok := str.Parts[0].(*ast.StringLiteral); !ok || lit.Value != "Hello " {
+		t.Errorf("parts[0] is not %q, got = %T (%s)", "Hello ", str.Parts[0], str.Parts[0])
+	}
+	testIdentifier(t, str.Parts[1], "name")
+	if lit, ok := str.Parts[2].(*ast.StringLiteral); !ok || lit.Value != ", you are " {
+		t.Errorf("parts[2] is not %q, got = %T (%s)", ", you are ", str.Parts[2], str.Parts[2])
+	}
+	testInfixExpression(t, str.Parts[3], "age", "+", 1)
+
+	expected := `"Hello ${name}, you are ${(age + 1)}"`
+	if str.String() != expected {
+		t.Errorf("str.String() wrong, expected = %q, got = %q", expected, str.String())
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
-	l := lexer.New(input)
+	l := lexer.New("", input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
@@ -200,7 +373,7 @@ func TestCallExpressionParsing(t *testing.T) {
 
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `fn(x, y) { x + y; }`
-	l := lexer.New(input)
+	l := lexer.New("", input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
@@ -247,62 +420,702 @@ func TestFunctionParameterParsing(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		l := lexer.New(tt.input)
+		l := lexer.New("", tt.input)
+		p := New(l)
+
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+
+		function := stmt.Expression.(*ast.FunctionLiteral)
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("length parameters wrong. want %d, got=%d\n", len(tt.expectedParams), len(function.Parameters))
+		}
+
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+	}
+}
+
+func TestFunctionDefaultAndRestParameters(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedParams   []string
+		expectedDefaults []string // "" for a parameter without a default
+		expectedRest     string
+		expectedString   string
+	}{
+		{"fn(a, b = 10) {}", []string{"a", "b"}, []string{"", "10"}, "", "fn(a, b = 10) "},
+		{"fn(a = 1 + 2, b = a * 2) {}", []string{"a", "b"}, []string{"(1 + 2)", "(a * 2)"}, "", "fn(a = (1 + 2), b = (a * 2)) "},
+		{"fn(...rest) {}", []string{}, []string{}, "rest", "fn(...rest) "},
+		{"fn(a, b = 10, ...rest) { rest }", []string{"a", "b"}, []string{"", "10"}, "rest", "fn(a, b = 10, ...rest) rest"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Fatalf("length parameters wrong. want %d, got=%d\n", len(tt.expectedParams), len(function.Parameters))
+		}
+		for i, ident := range tt.expectedParams {
+			if function.Parameters[i].Value != ident {
+				t.Errorf("parameter %d wrong. want=%q, got=%q", i, ident, function.Parameters[i].Value)
+			}
+
+			got := ""
+			if function.Defaults[i] != nil {
+				got = function.Defaults[i].String()
+			}
+			if got != tt.expectedDefaults[i] {
+				t.Errorf("default of %s wrong. want=%q, got=%q", ident, tt.expectedDefaults[i], got)
+			}
+		}
+
+		rest := ""
+		if function.Rest != nil {
+			rest = function.Rest.Value
+		}
+		if rest != tt.expectedRest {
+			t.Errorf("rest parameter wrong. want=%q, got=%q", tt.expectedRest, rest)
+		}
+		if function.String() != tt.expectedString {
+			t.Errorf("function.String() wrong. want=%q, got=%q", tt.expectedString, function.String())
+		}
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+		expectedString string
+	}{
+		{"x => x * 2", []string{"x"}, "(x) => (x * 2)"},
+		{"(a, b) => a + b", []string{"a", "b"}, "(a, b) => (a + b)"},
+		{"() => 1", []string{}, "() => 1"},
+		{"(x) => x", []string{"x"}, "(x) => x"},
+		{"(a, b = 10) => a + b", []string{"a", "b"}, "(a, b = 10) => (a + b)"},
+		{"(a, ...rest) => rest", []string{"a"}, "(a, ...rest) => rest"},
+		{"(...rest) => rest", []string{}, "(...rest) => rest"},
+		{"x => y => x + y", []string{"x"}, "(x) => (y) => (x + y)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("%q is not ast.FunctionLiteral. got=%T", tt.input, stmt.Expression)
+		}
+		if !function.IsArrow() {
+			t.Errorf("%q is not an arrow function", tt.input)
+		}
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Fatalf("length parameters wrong. want %d, got=%d\n", len(tt.expectedParams), len(function.Parameters))
+		}
+		for i, ident := range tt.expectedParams {
+			if function.Parameters[i].Value != ident {
+				t.Errorf("parameter %d wrong. want=%q, got=%q", i, ident, function.Parameters[i].Value)
+			}
+		}
+		if len(function.Body.Statements) != 1 {
+			t.Errorf("body is not 1 statement. got=%d", len(function.Body.Statements))
+		}
+		if function.String() != tt.expectedString {
+			t.Errorf("function.String() wrong. want=%q, got=%q", tt.expectedString, function.String())
+		}
+		if function.Pos().Offset != 0 || function.End().Offset != len(tt.input) {
+			t.Errorf("wrong span for %q. got=%d-%d", tt.input, function.Pos().Offset, function.End().Offset)
+		}
+	}
+}
+
+func TestArrowFunctionsInExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(a + b) * c", "((a + b) * c)"},
+		{"map(xs, x => x * 2)", "map(xs, (x) => (x * 2))"},
+		{"xs |> map((a, b) => a + b)", "map(xs, (a, b) => (a + b))"},
+		{"let f = x => x;", "let f = (x) => x;"},
+		{"[x => x, () => 1]", "[(x) => x, () => 1]"},
+		{"match (v) { n if (ok) => 1, n if f(x => x) => 2, _ => x => x }", "match(v) { n if ok => 1, n if f((x) => x) => 2, _ => (x) => x }"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for %q. want=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestArrowFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(a, b)", "expected next token error: expected = {=>} | got = {EOF}"},
+		{"()", "expected next token error: expected = {=>} | got = {EOF}"},
+		{"(...rest) + 1", "expected next token error: expected = {=>} | got = {+}"},
+		{"(a, 1) => a", "invalid parameter 1 in arrow function"},
+		{"(a + b) => a", "invalid parameter (a + b) in arrow function"},
+		{"(a = 1, b) => a", "parameter b without a default value follows a parameter with a default value"},
+		{"(a, ...rest, b) => a", "expected next token error: expected = {)} | got = {,}"},
+		{"((a)) => 1", "parameter a in arrow function must not be in parentheses"},
+		{"(a, (b = 2)) => a", "parameter b in arrow function must not be in parentheses"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("no error for %q", tt.input)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+
+	p := New(lexer.New("", "((a)) => 1"))
+	p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 1 || errors[0].Kind != InvalidParameter {
+		t.Errorf("((a)) => 1 is not rejected as InvalidParameter, got = %q", errors)
+	}
+}
+
+func TestFunctionParameterErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		kind     ErrorKind
+		expected string
+	}{
+		{"fn(a = 1, b) {}", InvalidParameter, "parameter b without a default value follows a parameter with a default value"},
+		{"fn(...rest, a) {}", UnexpectedToken, "expected next token error: expected = {)} | got = {,}"},
+		{"fn(...) {}", UnexpectedToken, "expected next token error: expected = {IDENT} | got = {)}"},
+		{"fn(1) {}", UnexpectedToken, "expected next token error: expected = {IDENT} | got = {INT}"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("no error for %q", tt.input)
+			continue
+		}
+		if errors[0].Kind != tt.kind {
+			t.Errorf("wrong error kind for %q. want=%s, got=%s", tt.input, tt.kind, errors[0].Kind)
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
+func TestIfExpression(t *testing.T) {
+	input := `if (x < y) { x }`
+	l := lexer.New("", input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Body does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T",
+			stmt.Expression)
+	}
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+	if len(exp.Consequence.Statements) != 1 {
+		t.Errorf("consequence is not 1 statements. got=%d\n",
+			len(exp.Consequence.Statements))
+	}
+	consequence, ok := exp.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T",
+			exp.Consequence.Statements[0])
+	}
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+	if exp.Alternative != nil {
+		t.Errorf("exp.Alternative.Statements was not nil. got=%+v", exp.Alternative)
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < 10) { let x = x + 1; }`
+	l := lexer.New("", input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. got=%T",
+			program.Statements[0])
+	}
+	if !testInfixExpression(t, stmt.Condition, "x", "<", 10) {
+		return
+	}
+	if len(stmt.Body.Statements) != 1 {
+		t.Fatalf("body is not 1 statements. got=%d\n", len(stmt.Body.Statements))
+	}
+	if !testLetStatement(t, stmt.Body.Statements[0], "x") {
+		return
+	}
+	if stmt.String() != "while(x < 10) let x = (x + 1);" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+func TestWhileStatementWithSemicolon(t *testing.T) {
+	input := `while (i < 3) { i += 1 }; i`
+	l := lexer.New("", input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			2, len(program.Statements))
+	}
+	if _, ok := program.Statements[0].(*ast.WhileStatement); !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. got=%T",
+			program.Statements[0])
+	}
+	if !testIdentifier(t, program.Statements[1].(*ast.ExpressionStatement).Expression, "i") {
+		return
+	}
+}
+
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < 1) { a } else if (x < 2) { b } else if (x < 3) { c } else { d }`
+	l := lexer.New("", input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+
+	// the chain is nested: every else if is the only statement of the alternative
+	conditions := []int64{1, 2, 3}
+	for i, limit := range conditions {
+		if !testInfixExpression(t, exp.Condition, "x", "<", limit) {
+			return
+		}
+		if i == len(conditions)-1 {
+			break
+		}
+		if !exp.IsElseIf() {
+			t.Fatalf("alternative %d is not an else if", i)
+		}
+		exp = exp.Alternative.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	}
+	if exp.IsElseIf() || exp.Alternative == nil {
+		t.Fatalf("last alternative is not a plain else block")
+	}
+
+	expected := "if(x < 1) aelse if(x < 2) belse if(x < 3) celse d"
+	if stmt.String() != expected {
+		t.Errorf("stmt.String() wrong. want=%q, got=%q", expected, stmt.String())
+	}
+	if stmt.End().Offset != len(input) {
+		t.Errorf("stmt.End() wrong. want offset=%d, got=%d", len(input), stmt.End().Offset)
+	}
+}
+
+func TestElseBlockWithNestedIf(t *testing.T) {
+	input := `if (x) { a } else { if (y) { b } }`
+	p := New(lexer.New("", input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	if exp.IsElseIf() {
+		t.Errorf("else block with a nested if is reported as else if")
+	}
+	if exp.String() != "ifx aelse ify b" {
+		t.Errorf("exp.String() wrong. got=%q", exp.String())
+	}
+}
+
+func TestElseIfErrors(t *testing.T) {
+	p := New(lexer.New("", `if (x) { a } else 5`))
+	p.ParseProgram()
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("wrong number of errors. want=1, got=%d", len(errors))
+	}
+	expected := "expected next token error: expected = {{, IF} | got = {INT}"
+	if errors[0].Message != expected {
+		t.Errorf("wrong error. want=%q, got=%q", expected, errors[0].Message)
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"const x = 5;", "const x = 5;"},
+		{"const limit = 10 * 2", "const limit = (10 * 2);"},
+		{"const [a, b] = xs;", "const [a, b] = xs;"},
+		{"const {name} = person;", "const {name} = person;"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.LetStatement. got=%T", program.Statements[0])
+		}
+		if !stmt.IsConst() {
+			t.Errorf("stmt.IsConst() is false for %q", tt.input)
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("stmt.String() wrong. want=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+
+	p := New(lexer.New("", "let x = 5;"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if program.Statements[0].(*ast.LetStatement).IsConst() {
+		t.Errorf("let statement is reported as const")
+	}
+}
+
+func TestDestructuringLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = xs;", "let [a, b] = xs;"},
+		{"let [a, b, ...tail] = xs;", "let [a, b, ...tail] = xs;"},
+		{"let [...all] = xs;", "let [...all] = xs;"},
+		{"let [] = xs;", "let [] = xs;"},
+		{"let {name, age: years} = person;", "let {name, age: years} = person;"},
+		{`let {"first-name": first} = person;`, "let {first-name: first} = person;"},
+		{"let [a, [b, c], {d}] = xs;", "let [a, [b, c], {d}] = xs;"},
+		{"let {address: {city, zip: [z]}} = person;", "let {address: {city, zip: [z]}} = person;"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.LetStatement. got=%T", program.Statements[0])
+		}
+		if stmt.Name != nil || stmt.Pattern == nil {
+			t.Fatalf("destructuring let has Name=%v, Pattern=%v", stmt.Name, stmt.Pattern)
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("stmt.String() wrong. want=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestDestructuringPatternNodes(t *testing.T) {
+	p := New(lexer.New("", "let [a, {b: [c]}, ...d] = xs;"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	pattern, ok := program.Statements[0].(*ast.LetStatement).Pattern.(*ast.ArrayPattern)
+	if !ok {
+		t.Fatalf("pattern is not ast.ArrayPattern")
+	}
+	if len(pattern.Elements) != 2 || pattern.Rest == nil || pattern.Rest.Value != "d" {
+		t.Fatalf("wrong array pattern: %s", pattern.String())
+	}
+	if ident, ok := pattern.Elements[0].(*ast.Identifier); !ok || ident.Value != "a" {
+		t.Errorf("first element is not identifier a. got=%s", pattern.Elements[0].String())
+	}
+	hash, ok := pattern.Elements[1].(*ast.HashPattern)
+	if !ok || len(hash.Pairs) != 1 || hash.Pairs[0].Key.Value != "b" {
+		t.Fatalf("second element is not the hash pattern {b: [c]}. got=%s", pattern.Elements[1].String())
+	}
+	if _, ok := hash.Pairs[0].Value.(*ast.ArrayPattern); !ok {
+		t.Errorf("value of b is not ast.ArrayPattern. got=%T", hash.Pairs[0].Value)
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, 1] = xs;", "unexpected token in pattern: expected = {IDENT, [, {} | got = {INT}"},
+		{"let [...a, b] = xs;", "expected next token error: expected = {]} | got = {,}"},
+		{`let {"name"} = person;`, "expected next token error: expected = {:} | got = {}}"},
+		{"let {1: a} = person;", "expected next token error: expected = {IDENT, STRING} | got = {INT}"},
+		{"let [a b] = xs;", "expected next token error: expected = {]} | got = {IDENT}"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("no error for %q", tt.input)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	input := `match (x) {
+		0 => "zero",
+		-1 => "minus one",
+		[first, ...rest] if first > 1 => rest,
+		{kind: "circle", r} => r,
+		_ => false,
+	}`
+	p := New(lexer.New("", input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MatchExpression. got=%T", stmt.Expression)
+	}
+	if !testIdentifier(t, exp.Subject, "x") {
+		return
+	}
+	if len(exp.Arms) != 5 {
+		t.Fatalf("wrong number of arms. want=5, got=%d", len(exp.Arms))
+	}
+
+	patterns := []string{"LiteralPattern", "LiteralPattern", "ArrayPattern", "HashPattern", "Identifier"}
+	for i, arm := range exp.Arms {
+		got := fmt.Sprintf("%T", arm.Pattern)
+		if got != "*ast."+patterns[i] {
+			t.Errorf("arm %d has wrong pattern. want=*ast.%s, got=%s", i, patterns[i], got)
+		}
+	}
+	if exp.Arms[2].Guard == nil || exp.Arms[2].Guard.String() != "(first > 1)" {
+		t.Errorf("arm 2 has wrong guard. got=%v", exp.Arms[2].Guard)
+	}
+
+	expected := `match(x) { 0 => zero, (-1) => minus one, [first, ...rest] if (first > 1) => rest, {kind: circle, r} => r, _ => false }`
+	if exp.String() != expected {
+		t.Errorf("exp.String() wrong.\nwant=%q\ngot =%q", expected, exp.String())
+	}
+}
+
+func TestMatchExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match x { _ => 1 }", "expected next token error: expected = {(} | got = {IDENT}"},
+		{"match (x) { _ 1 }", "expected next token error: expected = {=>} | got = {INT}"},
+		{"match (x) { _ => 1 _ => 2 }", "expected next token error: expected = {}} | got = {IDENT}"},
+		{"match (x) { fn => 1 }", "unexpected token in pattern: expected = {IDENT, [, {} | got = {FUNCTION}"},
+		{"let [1] = xs;", "unexpected token in pattern: expected = {IDENT, [, {} | got = {INT}"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("no error for %q", tt.input)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestAssignExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5;", "x = 5"},
+		{"x += y * 2;", "x += (y * 2)"},
+		{"x -= 1", "x -= 1"},
+		{"x *= 2", "x *= 2"},
+		{"x /= 2", "x /= 2"},
+		{"a = b = c;", "a = b = c"},
+		{"xs[0] = 1;", "(xs[0]) = 1"},
+		{`h["k"] += 1;`, "(h[k]) += 1"},
+		{"x = y || z;", "x = (y || z)"},
+		{"person.age += 1;", "(person.age) += 1"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New("", tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+				1, len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+				program.Statements[0])
+		}
+		if _, ok := stmt.Expression.(*ast.AssignExpression); !ok {
+			t.Fatalf("stmt.Expression is not ast.AssignExpression. got=%T", stmt.Expression)
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("stmt.String() wrong. want=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestInvalidAssignmentTarget(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 = x;", "cannot assign to 5"},
+		{"f() = 1;", "cannot assign to f()"},
+		{"a + b += 1;", "cannot assign to (a + b)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("wrong number of errors for %q. want=1, got=%d", tt.input, len(errors))
+			continue
+		}
+		if errors[0].Kind != InvalidAssignmentTarget {
+			t.Errorf("wrong error kind for %q. got=%s", tt.input, errors[0].Kind)
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
+		if errors[0].Pos.Column != 1 {
+			t.Errorf("error for %q does not start at the target. got column=%d", tt.input, errors[0].Pos.Column)
+		}
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	tests := []struct {
+		input     string
+		variables []string
+		iterable  string
+		expected  string
+	}{
+		{"for (x in xs) { x }", []string{"x"}, "xs", "for(x in xs) x"},
+		{"for (k, v in h) { break; }", []string{"k", "v"}, "h", "for(k, v in h) break;"},
+		{"for (i in range(10)) { continue }", []string{"i"}, "range(10)", "for(i in range(10)) continue;"},
+		{"for (x in range(3)) { n += x };", []string{"x"}, "range(3)", "for(x in range(3)) n += x"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New("", tt.input)
 		p := New(l)
-
 		program := p.ParseProgram()
 		checkParserErrors(t, p)
-
-		stmt := program.Statements[0].(*ast.ExpressionStatement)
-
-		function := stmt.Expression.(*ast.FunctionLiteral)
-
-		if len(function.Parameters) != len(tt.expectedParams) {
-			t.Errorf("length parameters wrong. want %d, got=%d\n", len(tt.expectedParams), len(function.Parameters))
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+				1, len(program.Statements))
 		}
-
-		for i, ident := range tt.expectedParams {
-			testLiteralExpression(t, function.Parameters[i], ident)
+		stmt, ok := program.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ForStatement. got=%T",
+				program.Statements[0])
+		}
+		if len(stmt.Variables) != len(tt.variables) {
+			t.Fatalf("wrong number of loop variables. want=%d, got=%d", len(tt.variables), len(stmt.Variables))
+		}
+		for i, name := range tt.variables {
+			if stmt.Variables[i].Value != name {
+				t.Errorf("loop variable %d wrong. want=%q, got=%q", i, name, stmt.Variables[i].Value)
+			}
+		}
+		if stmt.Iterable.String() != tt.iterable {
+			t.Errorf("stmt.Iterable wrong. want=%q, got=%q", tt.iterable, stmt.Iterable.String())
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("stmt.String() wrong. want=%q, got=%q", tt.expected, stmt.String())
 		}
 	}
 }
-func TestIfExpression(t *testing.T) {
-	input := `if (x < y) { x }`
-	l := lexer.New(input)
-	p := New(l)
-	program := p.ParseProgram()
-	checkParserErrors(t, p)
-	if len(program.Statements) != 1 {
-		t.Fatalf("program.Body does not contain %d statements. got=%d\n",
-			1, len(program.Statements))
-	}
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
-	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
-			program.Statements[0])
-	}
-	exp, ok := stmt.Expression.(*ast.IfExpression)
-	if !ok {
-		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T",
-			stmt.Expression)
-	}
-	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
-		return
-	}
-	if len(exp.Consequence.Statements) != 1 {
-		t.Errorf("consequence is not 1 statements. got=%d\n",
-			len(exp.Consequence.Statements))
-	}
-	consequence, ok := exp.Consequence.Statements[0].(*ast.ExpressionStatement)
-	if !ok {
-		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T",
-			exp.Consequence.Statements[0])
-	}
-	if !testIdentifier(t, consequence.Expression, "x") {
-		return
+
+func TestForStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for x in xs { x }", "expected next token error: expected = {(} | got = {IDENT}"},
+		{"for (x of xs) { x }", "expected next token error: expected = {IN} | got = {IDENT}"},
+		{"for (x, in xs) { x }", "expected next token error: expected = {IDENT} | got = {IN}"},
 	}
-	if exp.Alternative != nil {
-		t.Errorf("exp.Alternative.Statements was not nil. got=%+v", exp.Alternative)
+
+	for _, tt := range tests {
+		p := New(lexer.New("", tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("no error for %q", tt.input)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
 	}
 }
 
@@ -419,10 +1232,94 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"a * b % c",
+			"((a * b) % c)",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"-2 ** 2",
+			"(-(2 ** 2))",
+		},
+		{
+			"2 ** -1 * 3",
+			"((2 ** (-1)) * 3)",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b << 1 + c",
+			"(a & (b << (1 + c)))",
+		},
+		{
+			"a | b == c",
+			"((a | b) == c)",
+		},
+		{
+			"~a & b",
+			"((~a) & b)",
+		},
+		{
+			"a >> 1 < b << 2",
+			"((a >> 1) < (b << 2))",
+		},
+		{
+			"xs |> push(1) |> rest()",
+			"rest(push(xs, 1))",
+		},
+		{
+			"a + b |> f(c * d)",
+			"f((a + b), (c * d))",
+		},
+		{
+			"a || b |> f()",
+			"f((a || b))",
+		},
+		{
+			"x = xs |> len()",
+			"x = len(xs)",
+		},
+		{
+			"xs |> h.map(g)",
+			"(h.map)(xs, g)",
+		},
+		{
+			"-a.b * c.d[0]",
+			"((-(a.b)) * ((c.d)[0]))",
+		},
+		{
+			"people[0].name.first",
+			"(((people[0]).name).first)",
+		},
+		{
+			"xs.push(4).len() + 1",
+			"(((xs.push)(4).len)() + 1)",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a < b && c == d || !e",
+			"(((a < b) && (c == d)) || (!e))",
+		},
 	}
 
 	for _, tt := range test {
-		l := lexer.New(tt.input)
+		l := lexer.New("", tt.input)
 		p := New(l)
 		program := p.ParseProgram()
 		checkParserErrors(t, p)
@@ -450,13 +1347,17 @@ func TestParsingInfixExpression(t *testing.T) {
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"true && false", true, "&&", false},
+		{"true || false", true, "||", false},
 		{"true == true", true, "==", true},
 		{"true != false", true, "!=", false},
 		{"false == false", false, "==", false},
 	}
 
 	for _, tt := range infixTests {
-		l := lexer.New(tt.input)
+		l := lexer.New("", tt.input)
 		p := New(l)
 
 		program := p.ParseProgram()
@@ -504,7 +1405,7 @@ func TestParsingPrefixExpressions(t *testing.T) {
 	}
 
 	for _, tt := range prefixTests {
-		l := lexer.New(tt.input)
+		l := lexer.New("", tt.input)
 		p := New(l)
 
 		program := p.ParseProgram()
@@ -644,7 +1545,7 @@ func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
 func TestIntegerLiteralExpression(t *testing.T) {
 	input := "5;"
 
-	l := lexer.New(input)
+	l := lexer.New("", input)
 	p := New(l)
 
 	program := p.ParseProgram()
@@ -676,10 +1577,101 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestExtendedIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xff", 255},
+		{"0b1010", 10},
+		{"0o17", 15},
+		{"1_000_000", 1000000},
+		{"9223372036854775807", 9223372036854775807},
+		{"-9223372036854775808", -9223372036854775808},
+		{"-0x8000_0000_0000_0000", -9223372036854775808},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New("", tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+
+		if !ok {
+			t.Fatalf("exp not *ast.IntegerLiteral, got = %T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %d, got = %d", tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestIntegerLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"9223372036854775808", "1:1: integer literal 9223372036854775808 is out of range, integers must be between -9223372036854775808 and 9223372036854775807"},
+		{"x - 9223372036854775808", "1:5: integer literal 9223372036854775808 is out of range, integers must be between -9223372036854775808 and 9223372036854775807"},
+		{"x + 0xffffffffffffffffff", "1:5: integer literal 0xffffffffffffffffff is out of range, integers must be between -9223372036854775808 and 9223372036854775807"},
+		{"0b102", `1:1: could not parse "0b102" as integer`},
+		{"1__0", `1:1: could not parse "1__0" as integer`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New("", tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("wrong number of errors for %q. expected = 1, got = %d (%v)", tt.input, len(errors), errors)
+		}
+
+		if errors[0].String() != tt.expectedError {
+			t.Errorf("wrong error, expected = %q, got = %q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"1e3", 1000},
+		{"2.5e-1", 0.25},
+		{"1_000.5", 1000.5},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New("", tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral, got = %T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g, got = %g", tt.expected, literal.Value)
+		}
+	}
+}
+
 func TestIdentifierExpression(t *testing.T) {
 	input := "foobar;"
 
-	l := lexer.New(input)
+	l := lexer.New("", input)
 	p := New(l)
 
 	program := p.ParseProgram()
@@ -722,7 +1714,7 @@ func TestReturnStatements(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		l := lexer.New(tt.input)
+		l := lexer.New("", tt.input)
 		p := New(l)
 		program := p.ParseProgram()
 		checkParserErrors(t, p)
@@ -753,7 +1745,7 @@ func TestLetStatements(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		l := lexer.New(tt.input)
+		l := lexer.New("", tt.input)
 		p := New(l)
 		program := p.ParseProgram()
 		checkParserErrors(t, p)
@@ -786,7 +1778,7 @@ func checkParserErrors(t *testing.T, p *Parser) { // checks the parser for error
 	t.Errorf("parser has %d errors", len(errors))
 
 	for _, msg := range errors {
-		t.Errorf("parser error: %q", msg)
+		t.Errorf("parser error: %q", msg.String())
 	}
 
 	t.FailNow()
@@ -817,3 +1809,245 @@ func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 
 	return true
 }
+
+func TestNodePositions(t *testing.T) {
+	input := `let add = fn(x, y) {
+	x + y;
+};
+add(1, [2, 3][0])`
+
+	l := lexer.New("test.mkl", input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+
+	tests := []struct {
+		node        ast.Node
+		expectedPos string
+		expectedEnd string
+	}{
+		{program, "test.mkl:1:1", "test.mkl:4:18"},
+		{program.Statements[0], "test.mkl:1:1", "test.mkl:3:2"},
+		{program.Statements[0].(*ast.LetStatement).Value, "test.mkl:1:11", "test.mkl:3:2"},
+		{call, "test.mkl:4:1", "test.mkl:4:18"},
+		{call.Arguments[1], "test.mkl:4:8", "test.mkl:4:17"},
+	}
+
+	for i, tt := range tests {
+		if tt.node.Pos().String() != tt.expectedPos {
+			t.Errorf("tests[%d] - Pos() wrong. expected = %q, got = %q", i, tt.expectedPos, tt.node.Pos())
+		}
+		if tt.node.End().String() != tt.expectedEnd {
+			t.Errorf("tests[%d] - End() wrong. expected = %q, got = %q", i, tt.expectedEnd, tt.node.End())
+		}
+	}
+}
+
+func TestParserErrorPositions(t *testing.T) {
+	l := lexer.New("test.mkl", "let x 5;")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+
+	expected := "test.mkl:1:7: expected next token error: expected = {=} | got = {INT}"
+	if errors[0].String() != expected {
+		t.Errorf("wrong error. expected = %q, got = %q", expected, errors[0])
+	}
+}
+
+func TestLexerErrorsAreReported(t *testing.T) {
+	l := lexer.New("", `let s = "abc`)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("wrong number of errors. expected = 1, got = %d (%v)", len(errors), errors)
+	}
+
+	if errors[0].String() != "1:9: unterminated string literal" {
+		t.Errorf("wrong error, got = %q", errors[0])
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	input := `let x 5;
+let y = (1 + 2 * ;
+let ok = 10;
+fn(a) { let = 1; a + 1 };
+return 5 +
+let last = add(1, 2`
+
+	l := lexer.New("", input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	expectedErrors := []string{
+		"1:7: expected next token error: expected = {=} | got = {INT}",
+		"2:18: no prefix parse function for ; found",
+		"4:13: expected next token error: expected = {IDENT} | got = {=}",
+		"6:1: no prefix parse function for LET found",
+		"6:20: expected next token error: expected = {)} | got = {EOF}",
+	}
+
+	errors := p.Errors()
+	if len(errors) != len(expectedErrors) {
+		t.Fatalf("wrong number of errors. expected = %d, got = %d (%q)", len(expectedErrors), len(errors), errors)
+	}
+	for i, msg := range expectedErrors {
+		if errors[i].String() != msg {
+			t.Errorf("errors[%d] wrong. expected = %q, got = %q", i, msg, errors[i])
+		}
+	}
+
+	// the statements without errors are still in the program.
+	expected := "let ok = 10;fn(a) (a + 1)"
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. expected = %q, got = %q", expected, program.String())
+	}
+
+	// an error before a block skips the whole block, its closing } is not reported again.
+	tests := []struct {
+		input           string
+		expectedError   string
+		expectedProgram string
+	}{
+		{"if (a > b { return a; } else { return b; }; let ok = 1;", "1:11: expected next token error: expected = {)} | got = {{}", "let ok = 1;"},
+		{"while (x { x = 1; }; let ok = 1;", "1:10: expected next token error: expected = {)} | got = {{}", "let ok = 1;"},
+		{"for (x xs) { puts(x); }; let ok = 1;", "1:8: expected next token error: expected = {IN} | got = {IDENT}", "let ok = 1;"},
+		{"let f = fn(a b) { a }; let ok = 1;", "1:14: expected next token error: expected = {)} | got = {IDENT}", "let ok = 1;"},
+		{"fn(x { x }; let ok = 1;", "1:6: expected next token error: expected = {)} | got = {{}", "let ok = 1;"},
+		{`let h = {"a" 1}; let ok = 1;`, "1:14: expected next token error: expected = {:} | got = {INT}", "let ok = 1;"},
+		// the error is at the } which closes the enclosing block.
+		{"fn(a) { a + }; let ok = 1;", "1:13: no prefix parse function for } found", "fn(a) let ok = 1;"},
+		{"fn() { if (x { 1 } }; let ok = 1;", "1:14: expected next token error: expected = {)} | got = {{}", "fn() let ok = 1;"},
+		{`fn() { let h = {"a": 1; let ok = 1; }; let b = 2;`, "1:23: expected next token error: expected = {,} | got = {;}", "fn() let ok = 1;let b = 2;"},
+		// a statement whose condition failed is left out, its body is not parsed on its own.
+		{"if (]) { 1 }", "1:5: no prefix parse function for ] found", ""},
+		{"while ( ] ) { y }", "1:9: no prefix parse function for ] found", ""},
+		{"for (x in ]) { 1 }", "1:11: no prefix parse function for ] found", ""},
+		{"let a = if (]) { 1 }; let ok = 1;", "1:13: no prefix parse function for ] found", "let ok = 1;"},
+		// at the top level there is no block to close, the stray } is skipped.
+		{"let x = (1 + ) }; let ok = 1;", "1:14: no prefix parse function for ) found", "let ok = 1;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New("", tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("%q - wrong number of errors. expected = 1, got = %d (%q)", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].String() != tt.expectedError {
+			t.Errorf("%q - wrong error. expected = %q, got = %q", tt.input, tt.expectedError, errors[0])
+		}
+		if program.String() != tt.expectedProgram {
+			t.Errorf("%q - program.String() wrong. expected = %q, got = %q", tt.input, tt.expectedProgram, program.String())
+		}
+	}
+}
+
+func TestErrorRecoveryAfterUnclosedHash(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{`let h = {"a": 1; let y = ; let z = 3 +;`, []string{
+			"1:16: expected next token error: expected = {,} | got = {;}",
+			"1:26: no prefix parse function for ; found",
+			"1:39: no prefix parse function for ; found",
+		}},
+		{`let {"a": b = h; let y = ; let z = 3 +;`, []string{
+			"1:13: expected next token error: expected = {}} | got = {=}",
+			"1:26: no prefix parse function for ; found",
+			"1:39: no prefix parse function for ; found",
+		}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New("", tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("%q - wrong number of errors. expected = %d, got = %d (%q)", tt.input, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+		for i, msg := range tt.expectedErrors {
+			if errors[i].String() != msg {
+				t.Errorf("%q - errors[%d] wrong. expected = %q, got = %q", tt.input, i, msg, errors[i])
+			}
+		}
+	}
+}
+
+func TestIllegalTokenReportedOnce(t *testing.T) {
+	l := lexer.New("", "let @ = 5; let ok = 1;")
+	p := New(l)
+	program := p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("wrong number of errors. expected = 1, got = %d (%q)", len(errors), errors)
+	}
+	if errors[0].Kind != LexicalError || errors[0].String() != "1:5: illegal character '@'" {
+		t.Errorf("wrong error, got = %s %q", errors[0].Kind, errors[0])
+	}
+	if program.String() != "let ok = 1;" {
+		t.Errorf("program.String() wrong. got = %q", program.String())
+	}
+}
+
+func TestParseErrorDetails(t *testing.T) {
+	input := `let x 5;
+let y = 99999999999999999999;
+let z = "abc`
+
+	l := lexer.New("test.mkl", input)
+	p := New(l)
+	p.ParseProgram()
+
+	tests := []struct {
+		kind        ErrorKind
+		expected    []token.TokenType
+		found       token.TokenType
+		expectedPos string
+		expectedEnd string
+	}{
+		{UnexpectedToken, []token.TokenType{token.ASSIGN}, token.INT, "test.mkl:1:7", "test.mkl:1:8"},
+		{IntegerOutOfRange, nil, token.INT, "test.mkl:2:9", "test.mkl:2:29"},
+		{LexicalError, nil, "", "test.mkl:3:9", "test.mkl:3:13"},
+	}
+
+	errors := p.Errors()
+	if len(errors) != len(tests) {
+		t.Fatalf("wrong number of errors. expected = %d, got = %d (%q)", len(tests), len(errors), errors)
+	}
+
+	for i, tt := range tests {
+		err := errors[i]
+
+		if err.Kind != tt.kind {
+			t.Errorf("errors[%d] - kind wrong. expected = %s, got = %s", i, tt.kind, err.Kind)
+		}
+		if fmt.Sprint(err.Expected) != fmt.Sprint(tt.expected) {
+			t.Errorf("errors[%d] - expected tokens wrong. expected = %v, got = %v", i, tt.expected, err.Expected)
+		}
+		if err.Found.Type != tt.found {
+			t.Errorf("errors[%d] - found token wrong. expected = %q, got = %q", i, tt.found, err.Found.Type)
+		}
+		if err.Pos.String() != tt.expectedPos || err.End.String() != tt.expectedEnd {
+			t.Errorf("errors[%d] - span wrong. expected = %s-%s, got = %s-%s", i, tt.expectedPos, tt.expectedEnd, err.Pos, err.End)
+		}
+	}
+}
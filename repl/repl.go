@@ -8,7 +8,7 @@ import (
 	"monkeylang/lexer"
 	"monkeylang/object"
 	"monkeylang/parser"
-	//"os"
+	"os"
 )
 
 const PROMPT = ">> "
@@ -20,7 +20,7 @@ func Start(in io.Reader, out io.Writer) {
 		panic(err)
 	}
 	env := object.NewEnvironment()
-	l := lexer.New(string(dat))
+	l := lexer.New("scratch/input.mkl", string(dat))
 	p := parser.New(l)
 
 	program := p.ParseProgram()
@@ -43,9 +43,9 @@ func Start(in io.Reader, out io.Writer) {
 	}
 }
 */
-func printParserErrors(out io.Writer, errors []string) {
+func printParserErrors(out io.Writer, errors []*parser.ParseError) {
 	for _, msg := range errors {
-		_, err := io.WriteString(out, "\t"+msg+"\n")
+		_, err := io.WriteString(out, "\t"+msg.String()+"\n")
 
 		if err != nil {
 			fmt.Printf("Error writing errors to output: %s", err)
@@ -54,18 +54,18 @@ func printParserErrors(out io.Writer, errors []string) {
 }
 
 func Start(in io.Reader, out io.Writer) {
-    scanner := bufio.NewScanner(in)
+    // a bufio.Reader has no limit on the length of a line, unlike a bufio.Scanner.
+    reader := bufio.NewReader(in)
     env := object.NewEnvironment()
 
     for {
         fmt.Printf(PROMPT)
-        scanned := scanner.Scan()
-        if !scanned {
+        line, err := reader.ReadString('\n')
+        if err != nil && line == "" {
             return
         }
 
-        line := scanner.Text()
-        l := lexer.New(line)
+        l := lexer.New("", line)
         p := parser.New(l)
 
         program := p.ParseProgram()
@@ -89,3 +89,35 @@ func Start(in io.Reader, out io.Writer) {
         }
     }
 }
+
+// runs a Monkey file, the file is read while it is parsed so it never has to fit in memory at once.
+// it returns an error if the file cannot be opened or the program fails.
+func RunFile(filename string, out io.Writer) error {
+    f, err := os.Open(filename)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    l := lexer.NewReader(filename, f)
+    p := parser.New(l)
+
+    program := p.ParseProgram()
+
+    if len(p.Errors()) != 0 {
+        printParserErrors(out, p.Errors())
+        return fmt.Errorf("%s: %d syntax errors", filename, len(p.Errors()))
+    }
+
+    evaluated := evaluator.Eval(program, object.NewEnvironment())
+
+    if errObj, ok := evaluated.(*object.Error); ok {
+        _, err := io.WriteString(out, errObj.Inspect()+"\n")
+        if err != nil {
+            fmt.Printf("Error writing the output: %s", err)
+        }
+        return fmt.Errorf("%s: runtime error", filename)
+    }
+
+    return nil
+}
@@ -1,11 +1,57 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 // basic building block of our language.
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // position of the first character of the token
+	End     Position // position immediately after the last character of the token
+
+	// comments are not part of the grammar, but we keep them around the token for tools like formatters.
+	Leading  []Comment // comments between the previous token and this one
+	Trailing []Comment // comments after this token on the same line
+}
+
+// Comment is a // line comment or a /* block comment */, the text includes the delimiters.
+type Comment struct {
+	Text string
+	Pos  Position
+	End  Position
+}
+
+// Position is a location in the source code.
+type Position struct {
+	Filename string // empty if the source has no file name (e.g. the REPL)
+	Offset   int    // byte offset, starting at 0
+	Line     int    // line number, starting at 1
+	Column   int    // column number, starting at 1
+}
+
+// a position is valid if it has a line number, the zero value is used for nodes that were not created by the lexer.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// formats the position as file:line:column, leaving out the parts we do not know.
+func (p Position) String() string {
+	s := p.Filename
+
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+
+	if s == "" {
+		s = "-"
+	}
+
+	return s
 }
 
 const (
@@ -20,6 +66,24 @@ const (
 	GT       = ">"
 	EQ       = "=="
 	NOT_EQ   = "!="
+	LT_EQ    = "<="
+	GT_EQ    = ">="
+	AND      = "&&"
+	OR       = "||"
+	PIPE     = "|>"
+	PERCENT  = "%"
+	POWER    = "**"
+	BIT_AND  = "&"
+	BIT_OR   = "|"
+	BIT_XOR  = "^"
+	BIT_NOT  = "~"
+	SHL      = "<<"
+	SHR      = ">>"
+
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
 
 	// Delimiters
 	COMMA     = ","
@@ -31,20 +95,36 @@ const (
 	LBRACKET  = "["
 	RBRACKET  = "]"
 	COLON     = ":"
+	ARROW     = "=>"
+	ELLIPSIS  = "..."
+	DOT       = "."
 
 	// Identifiers + literals
 	IDENT  = "IDENT" // add, foobar, x, y, ...
 	INT    = "INT"   // 1343456
+	FLOAT  = "FLOAT" // 3.14, 1e10, 2.5e-3
 	STRING = "STRING"
 
+	// an interpolated string "a ${x} b ${y} c" is split into INTERP_START "a ", x, INTERP_MIDDLE " b ", y, INTERP_END " c"
+	INTERP_START  = "INTERP_START"
+	INTERP_MIDDLE = "INTERP_MIDDLE"
+	INTERP_END    = "INTERP_END"
+
 	// Keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	MATCH    = "MATCH"
 
 	// Special Keywords
 	ILLEGAL = "ILLEGAL" // A keyword which is not recognized
@@ -53,13 +133,20 @@ const (
 
 // map of keywords (parts of language)
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"const":    CONST,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"match":    MATCH,
 }
 
 // looks at the map for possible keywords, or else returns IDENT (identifier)